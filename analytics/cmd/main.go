@@ -26,7 +26,7 @@ func main() {
 
 	log.Info("starting analytics", slog.Any("env", cfg))
 
-	topics := []string{cfg.Kafka.TopicUser, cfg.Kafka.TopicLink}
+	topics := []string{cfg.Kafka.TopicUser, cfg.Kafka.TopicLink, cfg.Kafka.TopicClick}
 
 	kafkaConsumer := kafka.NewConsumer(
 		cfg.Kafka.Brokers,
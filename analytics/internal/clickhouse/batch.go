@@ -25,17 +25,42 @@ func InsertUserEvents(ctx context.Context, conn clickhouse.Conn, events []UserEv
 
 func InsertLinkEvents(ctx context.Context, conn clickhouse.Conn, events []LinkEvent) error {
 	batch, err := conn.PrepareBatch(ctx,
-		`INSERT INTO default.link_events (event_type, link_id, user_id, alias, target_url, ts, raw)`,
+		`INSERT INTO default.link_events (event_type, link_id, user_id, alias, target_url, old_target_url, tags, utm_source, utm_medium, utm_campaign, utm_term, utm_content, ts, raw)`,
 	)
 	if err != nil {
 		return err
 	}
 
 	for _, e := range events {
-		if err := batch.Append(e.Type, e.LinkID, e.UserID, e.Alias, e.URL, e.Timestamp, e.RawJSON); err != nil {
+		if err := batch.Append(e.Type, e.LinkID, e.UserID, e.Alias, e.URL, e.OldURL, tags(e.Tags), e.Source, e.Medium, e.Campaign, e.Term, e.Content, e.Timestamp, e.RawJSON); err != nil {
 			return err
 		}
 	}
 
 	return batch.Send()
 }
+
+func InsertClickEvents(ctx context.Context, conn clickhouse.Conn, events []ClickEvent) error {
+	batch, err := conn.PrepareBatch(ctx,
+		`INSERT INTO default.click_events (event_type, link_id, alias, referrer, user_agent, ip, request_id, variant, tags, utm_source, utm_medium, utm_campaign, utm_term, utm_content, ts, raw)`,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if err := batch.Append(e.Type, e.LinkID, e.Alias, e.Referrer, e.UserAgent, e.Ip, e.RequestID, e.Variant, tags(e.Tags), e.Source, e.Medium, e.Campaign, e.Term, e.Content, e.Timestamp, e.RawJSON); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+// tags keeps the column non-null for events published before links had tags
+func tags(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -14,11 +14,38 @@ type UserEvent struct {
 }
 
 type LinkEvent struct {
-	Type      string      `json:"type" ch:"event_type"`
-	LinkID    uint64      `json:"link_id" ch:"link_id"`
-	UserID    uint64      `json:"user_id" ch:"user_id"`
-	Alias     string      `json:"alias" ch:"alias"`
-	URL       string      `json:"url" ch:"target_url"`
+	Type   string   `json:"type" ch:"event_type"`
+	LinkID uint64   `json:"link_id" ch:"link_id"`
+	UserID uint64   `json:"user_id" ch:"user_id"`
+	Alias  string   `json:"alias" ch:"alias"`
+	URL    string   `json:"url" ch:"target_url"`
+	OldURL string   `json:"old_url" ch:"old_target_url"`
+	Tags   []string `json:"tags" ch:"tags"`
+	UTM
+	Timestamp time.Time   `json:"timestamp" ch:"ts"`
+	RawJSON   interface{} `json:"raw_json" ch:"raw"`
+}
+
+type ClickEvent struct {
+	Type      string   `json:"type" ch:"event_type"`
+	LinkID    uint64   `json:"link_id" ch:"link_id"`
+	Alias     string   `json:"alias" ch:"alias"`
+	Referrer  string   `json:"referrer" ch:"referrer"`
+	UserAgent string   `json:"user_agent" ch:"user_agent"`
+	Ip        string   `json:"ip" ch:"ip"`
+	RequestID string   `json:"request_id" ch:"request_id"`
+	Variant   string   `json:"variant" ch:"variant"`
+	Tags      []string `json:"tags" ch:"tags"`
+	UTM
 	Timestamp time.Time   `json:"timestamp" ch:"ts"`
 	RawJSON   interface{} `json:"raw_json" ch:"raw"`
 }
+
+// UTM holds campaign parameters of the link target
+type UTM struct {
+	Source   string `json:"utm_source" ch:"utm_source"`
+	Medium   string `json:"utm_medium" ch:"utm_medium"`
+	Campaign string `json:"utm_campaign" ch:"utm_campaign"`
+	Term     string `json:"utm_term" ch:"utm_term"`
+	Content  string `json:"utm_content" ch:"utm_content"`
+}
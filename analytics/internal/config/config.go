@@ -13,10 +13,11 @@ type Config struct {
 }
 
 type KafkaStorage struct {
-	Brokers   []string `yaml:"brokers"`
-	TopicUser string   `yaml:"topic_user"`
-	TopicLink string   `yaml:"topic_link"`
-	GroupID   string   `yaml:"group_id"`
+	Brokers    []string `yaml:"brokers"`
+	TopicUser  string   `yaml:"topic_user"`
+	TopicLink  string   `yaml:"topic_link"`
+	TopicClick string   `yaml:"topic_click"`
+	GroupID    string   `yaml:"group_id"`
 }
 
 type ClickhouseStorage struct {
@@ -19,12 +19,13 @@ const (
 )
 
 type AnalyticsService struct {
-	r               *kafka.Reader
-	log             *slog.Logger
-	UserEventBuffer []ch.UserEvent
-	LinkEventBuffer []ch.LinkEvent
-	lastFlush       time.Time
-	conn            clickhouse.Conn
+	r                *kafka.Reader
+	log              *slog.Logger
+	UserEventBuffer  []ch.UserEvent
+	LinkEventBuffer  []ch.LinkEvent
+	ClickEventBuffer []ch.ClickEvent
+	lastFlush        time.Time
+	conn             clickhouse.Conn
 }
 
 func NewConsumer(brokers, topics []string, groupID string, log *slog.Logger, conn clickhouse.Conn) *AnalyticsService {
@@ -71,12 +72,19 @@ func (s *AnalyticsService) Start(ctx context.Context) {
 					continue
 				}
 				s.LinkEventBuffer = append(s.LinkEventBuffer, event)
+			case "click-events":
+				event, err := parseClickEvent(msg.Value)
+				if err != nil {
+					s.log.Error("could not parse click-event message")
+					continue
+				}
+				s.ClickEventBuffer = append(s.ClickEventBuffer, event)
 			default:
 				s.log.Warn("unknown topic", slog.String("topic", msg.Topic))
 				continue
 			}
 
-			shouldFlush := len(s.UserEventBuffer)+len(s.LinkEventBuffer) >= maxBatchSize || time.Since(s.lastFlush) >= maxBatchAge
+			shouldFlush := len(s.UserEventBuffer)+len(s.LinkEventBuffer)+len(s.ClickEventBuffer) >= maxBatchSize || time.Since(s.lastFlush) >= maxBatchAge
 
 			if shouldFlush {
 				if err := s.flush(); err != nil {
@@ -108,6 +116,13 @@ func (s *AnalyticsService) flush() error {
 		s.LinkEventBuffer = s.LinkEventBuffer[:0]
 	}
 
+	if len(s.ClickEventBuffer) > 0 {
+		if err := ch.InsertClickEvents(ctx, s.conn, s.ClickEventBuffer); err != nil {
+			return err
+		}
+		s.ClickEventBuffer = s.ClickEventBuffer[:0]
+	}
+
 	s.lastFlush = time.Now()
 
 	return nil
@@ -143,6 +158,32 @@ func parseLinkEvent(data []byte) (ch.LinkEvent, error) {
 		UserID:    raw.UserID,
 		Alias:     raw.Alias,
 		URL:       raw.URL,
+		OldURL:    raw.OldURL,
+		Tags:      raw.Tags,
+		UTM:       raw.UTM,
+		Timestamp: raw.Timestamp,
+		RawJSON:   string(data),
+	}, nil
+}
+
+func parseClickEvent(data []byte) (ch.ClickEvent, error) {
+	var raw ch.ClickEvent
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return ch.ClickEvent{}, err
+	}
+
+	return ch.ClickEvent{
+		Type:      raw.Type,
+		LinkID:    raw.LinkID,
+		Alias:     raw.Alias,
+		Referrer:  raw.Referrer,
+		UserAgent: raw.UserAgent,
+		Ip:        raw.Ip,
+		RequestID: raw.RequestID,
+		Variant:   raw.Variant,
+		Tags:      raw.Tags,
+		UTM:       raw.UTM,
 		Timestamp: raw.Timestamp,
 		RawJSON:   string(data),
 	}, nil
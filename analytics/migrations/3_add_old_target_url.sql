@@ -0,0 +1 @@
+ALTER TABLE default.link_events ADD COLUMN IF NOT EXISTS old_target_url String DEFAULT '' AFTER target_url;
@@ -0,0 +1 @@
+ALTER TABLE default.link_events DROP COLUMN IF EXISTS old_target_url
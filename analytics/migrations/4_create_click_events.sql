@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS default.click_events
+(
+    event_type String,
+    link_id UInt64,
+    alias String,
+    referrer String,
+    user_agent String,
+    ip String,
+    request_id String,
+    ts DateTime DEFAULT now(),
+    raw String
+)
+
+ENGINE = MergeTree()
+PARTITION BY toYYYYMM(ts)
+ORDER BY (link_id, ts)
+TTL ts + INTERVAL 90 DAY;
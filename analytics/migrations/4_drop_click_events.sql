@@ -0,0 +1 @@
+DROP TABLE IF EXISTS default.click_events
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE default.click_events ADD COLUMN IF NOT EXISTS variant String DEFAULT '' AFTER request_id;
@@ -0,0 +1 @@
+ALTER TABLE default.click_events DROP COLUMN IF EXISTS variant
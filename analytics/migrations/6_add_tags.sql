@@ -0,0 +1,2 @@
+ALTER TABLE default.link_events ADD COLUMN IF NOT EXISTS tags Array(String) AFTER old_target_url;
+ALTER TABLE default.click_events ADD COLUMN IF NOT EXISTS tags Array(String) AFTER variant;
@@ -0,0 +1,2 @@
+ALTER TABLE default.link_events DROP COLUMN IF EXISTS tags;
+ALTER TABLE default.click_events DROP COLUMN IF EXISTS tags
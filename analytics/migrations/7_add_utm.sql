@@ -0,0 +1,12 @@
+ALTER TABLE default.link_events
+    ADD COLUMN IF NOT EXISTS utm_source String DEFAULT '' AFTER tags,
+    ADD COLUMN IF NOT EXISTS utm_medium String DEFAULT '' AFTER utm_source,
+    ADD COLUMN IF NOT EXISTS utm_campaign String DEFAULT '' AFTER utm_medium,
+    ADD COLUMN IF NOT EXISTS utm_term String DEFAULT '' AFTER utm_campaign,
+    ADD COLUMN IF NOT EXISTS utm_content String DEFAULT '' AFTER utm_term;
+ALTER TABLE default.click_events
+    ADD COLUMN IF NOT EXISTS utm_source String DEFAULT '' AFTER tags,
+    ADD COLUMN IF NOT EXISTS utm_medium String DEFAULT '' AFTER utm_source,
+    ADD COLUMN IF NOT EXISTS utm_campaign String DEFAULT '' AFTER utm_medium,
+    ADD COLUMN IF NOT EXISTS utm_term String DEFAULT '' AFTER utm_campaign,
+    ADD COLUMN IF NOT EXISTS utm_content String DEFAULT '' AFTER utm_term;
@@ -0,0 +1,12 @@
+ALTER TABLE default.link_events
+    DROP COLUMN IF EXISTS utm_source,
+    DROP COLUMN IF EXISTS utm_medium,
+    DROP COLUMN IF EXISTS utm_campaign,
+    DROP COLUMN IF EXISTS utm_term,
+    DROP COLUMN IF EXISTS utm_content;
+ALTER TABLE default.click_events
+    DROP COLUMN IF EXISTS utm_source,
+    DROP COLUMN IF EXISTS utm_medium,
+    DROP COLUMN IF EXISTS utm_campaign,
+    DROP COLUMN IF EXISTS utm_term,
+    DROP COLUMN IF EXISTS utm_content
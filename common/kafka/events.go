@@ -6,4 +6,13 @@ const (
 	EventUserLoggedOut  = "user.logged.out"
 	EventLinkSaved      = "link.saved"
 	EventLinkDeleted    = "link.deleted"
+	EventLinkUpdated    = "link.updated"
+	EventLinkClicked    = "link.clicked"
+	EventLinkExpired    = "link.expired"
+	EventLinkBlocked    = "link.blocked"
+	EventLinkRestored   = "link.restored"
+	EventLinkPurged     = "link.purged"
+	EventLinkPaused     = "link.paused"
+	EventLinkResumed    = "link.resumed"
+	EventLinkActivated  = "link.activated"
 )
@@ -36,6 +36,33 @@ func (p *Producer) Publish(ctx context.Context, key string, value interface{}) e
 	return p.w.WriteMessages(ctx, msg)
 }
 
+// Message is a single record for PublishBatch
+type Message struct {
+	Key   string
+	Value interface{}
+}
+
+// PublishBatch writes several messages with one request to the broker
+func (p *Producer) PublishBatch(ctx context.Context, messages ...Message) error {
+	msgs := make([]kafka.Message, 0, len(messages))
+	now := time.Now()
+
+	for _, m := range messages {
+		b, err := json.Marshal(m.Value)
+		if err != nil {
+			return err
+		}
+
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(m.Key),
+			Value: b,
+			Time:  now,
+		})
+	}
+
+	return p.w.WriteMessages(ctx, msgs...)
+}
+
 func (p *Producer) Close() error {
 	return p.w.Close()
 }
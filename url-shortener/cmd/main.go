@@ -4,23 +4,51 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"image"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/lostmyescape/link-shortener/common/kafka"
 	"github.com/lostmyescape/link-shortener/common/logger/slogpretty"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/clicks"
 	ssogrpc "github.com/lostmyescape/link-shortener/url-shortener/internal/clients/sso/grpc"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/config"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/domainref"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/deleteURL"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/domains"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/folders"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/pages"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/redirect"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/tags"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/batch"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/list"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/qr"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/rules"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/save"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/trash"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/update"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/variants"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/utm"
 	mwLogger "github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/logger/middleware"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/realip"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/janitor"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/alias"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/attempts"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/domainverify"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/geoip"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	qrlib "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/qr"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
 	dbstorage "github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage/cache"
+	redisClient "github.com/lostmyescape/link-shortener/url-shortener/internal/storage/redis"
+	"github.com/redis/go-redis/v9"
 )
 
 const (
@@ -34,11 +62,31 @@ func main() {
 	cfg := config.LoadConfig()
 	log := setupLogger(cfg.Env)
 	storage := dbstorage.NewStorage(ctx, cfg, log)
+	rdb := redisClient.NewClient(cfg)
+	linkStorage := cache.New(log, storage, rdb, cfg.RedisStorage.LinkTTL, cfg.RedisStorage.NotFoundTTL)
 
 	log.Info("starting url-shortener", slog.Any("env", cfg))
 
 	producerProvider := kafka.NewProducer(cfg.Kafka.Brokers, cfg.Kafka.Topic)
 
+	clickPublisher := clicks.NewPublisher(
+		log,
+		kafka.NewProducer(cfg.Kafka.Brokers, cfg.Kafka.ClickTopic),
+		cfg.Kafka.ClickBuffer,
+	)
+	clickPublisher.Start()
+	defer clickPublisher.Close()
+
+	janitor.New(
+		log,
+		linkStorage,
+		producerProvider,
+		cfg.Janitor.Interval,
+		cfg.Janitor.Archive,
+		cfg.Janitor.BatchSize,
+		cfg.Janitor.TrashRetention,
+	).Start(ctx)
+
 	ssoClient, err := ssogrpc.New(
 		log,
 		cfg.Clients.SSO.Address,
@@ -49,7 +97,12 @@ func main() {
 		log.Error("failed to init sso client", sl.Err(err))
 		os.Exit(1)
 	}
-	ssoClient.IsAdmin(context.Background(), 1)
+
+	defer func(rdb *redis.Client) {
+		if err := rdb.Close(); err != nil {
+			log.Error("failed to close Redis", sl.Err(err))
+		}
+	}(rdb)
 
 	defer func(DB *sql.DB) {
 		err := storage.DB.Close()
@@ -59,9 +112,16 @@ func main() {
 		}
 	}(storage.DB)
 
+	trustedProxies, err := realip.ParsePrefixes(cfg.HTTPServer.TrustedProxies)
+	if err != nil {
+		log.Error("failed to parse trusted proxies", sl.Err(err))
+		os.Exit(1)
+	}
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
+	router.Use(realip.New(trustedProxies))
 	router.Use(middleware.Logger)
 	router.Use(mwLogger.New(log))
 	router.Use(middleware.Recoverer)
@@ -69,10 +129,146 @@ func main() {
 
 	jwtMiddleware := mdjwt.JWTMDConfig(cfg, log)
 
+	aliasValidator, err := alias.NewValidator(alias.Options{
+		Charset:       cfg.Aliases.Charset,
+		MinLength:     cfg.Aliases.MinLength,
+		MaxLength:     cfg.Aliases.MaxLength,
+		Reserved:      cfg.Aliases.Reserved,
+		BlocklistPath: cfg.Aliases.BlocklistPath,
+	})
+	if err != nil {
+		log.Error("failed to load alias rules", sl.Err(err))
+		os.Exit(1)
+	}
+
+	aliasGenerator, err := alias.NewGenerator(alias.GeneratorOptions{
+		Strategy: cfg.Aliases.Generator,
+		Length:   cfg.Aliases.Length,
+		Salt:     cfg.Aliases.Salt,
+	}, storage)
+	if err != nil {
+		log.Error("failed to init alias generator", sl.Err(err))
+		os.Exit(1)
+	}
+
+	if !models.ValidRedirectCode(cfg.Redirect.DefaultCode) {
+		log.Error("invalid default redirect code", slog.Int("code", cfg.Redirect.DefaultCode))
+		os.Exit(1)
+	}
+
+	selfHosts := cfg.URLPolicy.SelfHosts
+	if u, err := url.Parse(cfg.BaseURL); err == nil && u.Hostname() != "" {
+		selfHosts = append(selfHosts, u.Hostname())
+	}
+
+	urlPolicy, err := urlpolicy.New(urlpolicy.Options{
+		Schemes:       cfg.URLPolicy.Schemes,
+		DeniedHosts:   cfg.URLPolicy.DeniedHosts,
+		DeniedCIDRs:   cfg.URLPolicy.DeniedCIDRs,
+		SelfHosts:     selfHosts,
+		Domains:       linkStorage,
+		ResolveHosts:  cfg.URLPolicy.ResolveHosts,
+		BlocklistPath: cfg.URLPolicy.BlocklistPath,
+	})
+	if err != nil {
+		log.Error("failed to load url policy", sl.Err(err))
+		os.Exit(1)
+	}
+	urlPolicy.Watch(ctx, log, cfg.URLPolicy.ReloadInterval)
+
+	var geoDB *geoip.DB
+	if cfg.GeoIP.Path != "" {
+		geoDB, err = geoip.Open(cfg.GeoIP.Path)
+		if err != nil {
+			log.Error("failed to load geoip database, country rules will not match", sl.Err(err))
+		}
+	}
+
+	var qrLogo image.Image
+	if cfg.QR.LogoPath != "" {
+		qrLogo, err = qrlib.LoadLogo(cfg.QR.LogoPath)
+		if err != nil {
+			log.Error("failed to load qr logo, codes will be rendered without it", sl.Err(err))
+		}
+	}
+
 	router.Route("/url", func(r chi.Router) {
 		r.Use(jwtMiddleware.JWTAuthMiddleware)
-		r.Post("/", save.New(log, storage, producerProvider))
-		r.Delete("/{alias}", deleteURL.New(log, storage, producerProvider))
+		r.Get("/", list.New(log, storage))
+		r.Post("/", save.New(log, linkStorage, producerProvider, aliasValidator, aliasGenerator, urlPolicy, linkStorage, linkStorage))
+		r.Post("/batch", batch.New(log, linkStorage, producerProvider, aliasValidator, aliasGenerator, urlPolicy, linkStorage, linkStorage))
+		r.Get("/trash", trash.List(log, storage, cfg.Janitor.TrashRetention))
+		// links of custom domains are managed with ?domain=<host>
+		r.Group(func(r chi.Router) {
+			r.Use(domainref.New())
+			r.Patch("/{alias}", update.New(log, linkStorage, ssoClient, producerProvider, urlPolicy))
+			r.Delete("/{alias}", deleteURL.New(log, linkStorage, ssoClient, producerProvider))
+			r.Post("/{alias}/restore", trash.Restore(log, linkStorage, ssoClient, producerProvider))
+			r.Get("/{alias}/revisions", update.Revisions(log, linkStorage, ssoClient))
+			r.Get("/{alias}/qr", qr.New(log, linkStorage, cfg.BaseURL, qrLogo))
+			r.Post("/{alias}/revisions/{revision}/restore", update.Restore(log, linkStorage, ssoClient, producerProvider, urlPolicy))
+			r.Get("/{alias}/rules", rules.List(log, linkStorage, ssoClient))
+			r.Post("/{alias}/rules", rules.Create(log, linkStorage, ssoClient, urlPolicy))
+			r.Put("/{alias}/rules/{rule}", rules.Update(log, linkStorage, ssoClient, urlPolicy))
+			r.Delete("/{alias}/rules/{rule}", rules.Delete(log, linkStorage, ssoClient))
+			r.Get("/{alias}/variants", variants.Get(log, linkStorage, ssoClient))
+			r.Put("/{alias}/variants", variants.Put(log, linkStorage, ssoClient, urlPolicy))
+			r.Put("/{alias}/tags", update.Tags(log, linkStorage, ssoClient, linkStorage))
+			r.Put("/{alias}/folder", update.Folder(log, linkStorage, ssoClient, linkStorage))
+			r.Put("/{alias}/redirect", update.Redirect(log, linkStorage, ssoClient, linkStorage))
+			r.Put("/{alias}/forwarding", update.Forwarding(log, linkStorage, ssoClient, linkStorage))
+			r.Put("/{alias}/preview", update.Preview(log, linkStorage, ssoClient, linkStorage))
+			r.Put("/{alias}/deep-link", update.DeepLink(log, linkStorage, ssoClient, linkStorage, urlPolicy))
+			r.Post("/{alias}/pause", update.Pause(log, linkStorage, ssoClient, linkStorage, producerProvider))
+			r.Post("/{alias}/resume", update.Resume(log, linkStorage, ssoClient, linkStorage, producerProvider))
+		})
+	})
+
+	router.Route("/tags", func(r chi.Router) {
+		r.Use(jwtMiddleware.JWTAuthMiddleware)
+		r.Get("/", tags.List(log, linkStorage))
+		r.Post("/", tags.Create(log, linkStorage))
+		r.Patch("/{id}", tags.Rename(log, linkStorage))
+		r.Delete("/{id}", tags.Delete(log, linkStorage))
+	})
+
+	router.Route("/folders", func(r chi.Router) {
+		r.Use(jwtMiddleware.JWTAuthMiddleware)
+		r.Get("/", folders.List(log, linkStorage))
+		r.Post("/", folders.Create(log, linkStorage))
+		r.Patch("/{id}", folders.Rename(log, linkStorage))
+		r.Delete("/{id}", folders.Delete(log, linkStorage))
+	})
+
+	router.Route("/pages", func(r chi.Router) {
+		r.Use(jwtMiddleware.JWTAuthMiddleware)
+		r.Post("/", pages.Create(log, linkStorage, producerProvider, aliasValidator, cfg.BaseURL))
+		r.Get("/{alias}", pages.Get(log, linkStorage, ssoClient))
+		r.Put("/{alias}", pages.Update(log, linkStorage, ssoClient))
+	})
+
+	domainVerifier := domainverify.New(domainverify.Options{
+		Resolver: domainverify.NewResolver(cfg.Domains.Resolver),
+		Checker:  urlPolicy,
+		Control:  urlPolicy.Control,
+		Timeout:  cfg.Domains.Timeout,
+	})
+
+	router.Route("/domains", func(r chi.Router) {
+		r.Use(jwtMiddleware.JWTAuthMiddleware)
+		r.Get("/", domains.List(log, linkStorage))
+		r.Post("/", domains.Create(log, linkStorage, urlPolicy))
+		r.Post("/{id}/verify", domains.Verify(log, linkStorage, domainVerifier))
+		r.Put("/{id}", domains.Update(log, linkStorage, urlPolicy))
+		r.Delete("/{id}", domains.Delete(log, linkStorage))
+	})
+
+	router.Route("/utm-templates", func(r chi.Router) {
+		r.Use(jwtMiddleware.JWTAuthMiddleware)
+		r.Get("/", utm.List(log, linkStorage))
+		r.Post("/", utm.Create(log, linkStorage))
+		r.Put("/{id}", utm.Update(log, linkStorage))
+		r.Delete("/{id}", utm.Delete(log, linkStorage))
 	})
 
 	router.Route("/logout", func(r chi.Router) {
@@ -80,11 +276,33 @@ func main() {
 		r.Post("/", ssoClient.Logout(context.Background(), log))
 	})
 
-	router.Get("/{alias}", redirect.Redirect(log, storage))
+	redirectDomains := redirect.Domains{Resolver: linkStorage, ServiceHosts: selfHosts}
+
+	redirectHandler := redirect.Redirect(log, linkStorage, clickPublisher, geoDB, redirectDomains, redirect.Codes{
+		Default: cfg.Redirect.DefaultCode,
+		MaxAge:  cfg.Redirect.MaxAge,
+	})
+	router.Get("/{alias}", redirectHandler)
+	router.Head("/{alias}", redirectHandler)
+	router.Get("/{alias}/*", redirectHandler)
+	router.Head("/{alias}/*", redirectHandler)
+	unlockHandler := redirect.Unlock(
+		log,
+		linkStorage,
+		clickPublisher,
+		attempts.NewRedisStore(rdb, cfg.Passwords.MaxAttempts, cfg.Passwords.Window),
+		geoDB,
+		redirectDomains,
+	)
+	router.Post("/{alias}", unlockHandler)
+	router.Post("/{alias}/*", unlockHandler)
 	router.Post("/register", ssoClient.Register(context.Background(), log))
 	router.Post("/login", ssoClient.Login(context.Background(), log))
 	router.Get("/refresh", ssoClient.Refresh(context.Background(), log))
 
+	// aliases must not shadow any endpoint registered above
+	aliasValidator.Reserve(alias.ReservedFromRoutes(router)...)
+
 	log.Info("starting server", slog.String("address", cfg.HTTPServer.Address))
 
 	srv := &http.Server{
@@ -96,7 +314,7 @@ func main() {
 	}
 
 	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Error("failed to start server", err)
+		log.Error("failed to start server", sl.Err(err))
 	}
 
 	log.Error("server stopped")
@@ -0,0 +1,110 @@
+package clicks
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+)
+
+// Event describes a single redirect through a short link
+type Event struct {
+	Type      string    `json:"type"`
+	LinkID    int64     `json:"link_id"`
+	Alias     string    `json:"alias"`
+	Timestamp time.Time `json:"timestamp"`
+	Referrer  string    `json:"referrer"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	RequestID string    `json:"request_id"`
+	// Variant is the name of the split destination the visitor was sent to
+	Variant string `json:"variant,omitempty"`
+	// Tags of the link at the time of the click
+	Tags []string `json:"tags,omitempty"`
+	// UTM parameters of the target the visitor was sent to
+	models.UTM
+}
+
+//go:generate mockery --name=BatchProducer --dir=. --output=./mocks --filename=batch_producer_mock.go --outpkg=mocks
+type BatchProducer interface {
+	PublishBatch(ctx context.Context, messages ...kafka.Message) error
+}
+
+const (
+	maxBatchSize   = 500
+	publishTimeout = 10 * time.Second
+)
+
+// Publisher sends click events to Kafka in background,
+// so the redirect never waits for the broker
+type Publisher struct {
+	log      *slog.Logger
+	producer BatchProducer
+	queue    chan Event
+	wg       sync.WaitGroup
+}
+
+func NewPublisher(log *slog.Logger, producer BatchProducer, bufferSize int) *Publisher {
+	return &Publisher{
+		log:      log.With(slog.String("component", "clicks/publisher")),
+		producer: producer,
+		queue:    make(chan Event, bufferSize),
+	}
+}
+
+// Start runs the worker that drains the queue until Close is called
+func (p *Publisher) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		for ev := range p.queue {
+			batch := []kafka.Message{{Key: ev.Alias, Value: ev}}
+
+		drain:
+			for len(batch) < maxBatchSize {
+				select {
+				case ev, ok := <-p.queue:
+					if !ok {
+						break drain
+					}
+					batch = append(batch, kafka.Message{Key: ev.Alias, Value: ev})
+				default:
+					break drain
+				}
+			}
+
+			p.publish(batch)
+		}
+	}()
+}
+
+// Track enqueues the event, dropping it when the queue is full
+func (p *Publisher) Track(ev Event) {
+	ev.Type = kafka.EventLinkClicked
+
+	select {
+	case p.queue <- ev:
+	default:
+		p.log.Warn("click queue is full, event dropped", slog.String("alias", ev.Alias))
+	}
+}
+
+// Close flushes queued events and stops the worker
+func (p *Publisher) Close() {
+	close(p.queue)
+	p.wg.Wait()
+}
+
+func (p *Publisher) publish(batch []kafka.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if err := p.producer.PublishBatch(ctx, batch...); err != nil {
+		p.log.Error("failed to send click events to Kafka", slog.Int("count", len(batch)), sl.Err(err))
+	}
+}
@@ -0,0 +1,42 @@
+package clicks
+
+import (
+	"testing"
+
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/clicks/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublisher(t *testing.T) {
+	producerMock := mocks.NewBatchProducer(t)
+
+	var published []Event
+	producerMock.On("PublishBatch", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			for _, arg := range args[1:] {
+				msg := arg.(kafka.Message)
+				published = append(published, msg.Value.(Event))
+				require.Equal(t, "promo", msg.Key)
+			}
+		}).
+		Return(nil).
+		Once()
+
+	p := NewPublisher(slogdiscard.NewDiscardLogger(), producerMock, 2)
+
+	// queue holds two events, the third one is dropped
+	p.Track(Event{Alias: "promo", LinkID: 1})
+	p.Track(Event{Alias: "promo", LinkID: 2})
+	p.Track(Event{Alias: "promo", LinkID: 3})
+
+	p.Start()
+	p.Close()
+
+	require.Len(t, published, 2)
+	for _, ev := range published {
+		require.Equal(t, kafka.EventLinkClicked, ev.Type)
+	}
+}
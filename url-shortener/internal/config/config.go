@@ -10,12 +10,21 @@ import (
 type Config struct {
 	Env          string `yaml:"env"`
 	Address      string `yaml:"address"`
+	BaseURL      string `yaml:"base_url" env:"BASE_URL" env-default:"http://localhost:8080"`
 	HTTPServer   `yaml:"http_server"`
 	Clients      ClientsConfig `yaml:"clients"`
 	RedisStorage RedisStorage  `yaml:"redis"`
 	AppSecret    string        `yaml:"app_secret" env:"APP_SECRET"`
 	Kafka        KafkaStorage
-	GRPC         GRPCConfig `yaml:"grpc"`
+	GRPC         GRPCConfig      `yaml:"grpc"`
+	Janitor      JanitorConfig   `yaml:"janitor"`
+	Passwords    PasswordConfig  `yaml:"passwords"`
+	QR           QRConfig        `yaml:"qr"`
+	Aliases      AliasConfig     `yaml:"aliases"`
+	URLPolicy    URLPolicyConfig `yaml:"url_policy"`
+	GeoIP        GeoIPConfig     `yaml:"geoip"`
+	Redirect     RedirectConfig  `yaml:"redirect"`
+	Domains      DomainsConfig   `yaml:"domains"`
 	Storage      struct {
 		Host     string `yaml:"host"`
 		Port     int    `yaml:"port"`
@@ -33,6 +42,8 @@ type HTTPServer struct {
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
 	User        string        `yaml:"user" env-required:"true"`
 	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	// TrustedProxies are CIDRs of the proxies whose X-Forwarded-For and X-Real-IP are applied
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type Client struct {
@@ -47,16 +58,88 @@ type ClientsConfig struct {
 }
 
 type RedisStorage struct {
-	Addr     string `yaml:"addr"`
-	Password string `yaml:"password"`
-	DB       int    `yaml:"db"`
+	Addr        string        `yaml:"addr"`
+	Password    string        `yaml:"password"`
+	DB          int           `yaml:"db"`
+	LinkTTL     time.Duration `yaml:"link_ttl" env-default:"1h"`
+	NotFoundTTL time.Duration `yaml:"not_found_ttl" env-default:"1m"`
 }
 
 type KafkaStorage struct {
-	Brokers []string `yaml:"brokers"`
-	Topic   string   `yaml:"topic"`
-	Ip      string   `yaml:"ip"`
+	Brokers     []string `yaml:"brokers"`
+	Topic       string   `yaml:"topic"`
+	ClickTopic  string   `yaml:"click_topic" env-default:"click-events"`
+	ClickBuffer int      `yaml:"click_buffer" env-default:"10000"`
+	Ip          string   `yaml:"ip"`
 }
+
+type JanitorConfig struct {
+	Interval  time.Duration `yaml:"interval" env-default:"1m"`
+	Archive   bool          `yaml:"archive"`
+	BatchSize int           `yaml:"batch_size" env-default:"500"`
+	// TrashRetention is how long deleted links can be restored before they are purged
+	TrashRetention time.Duration `yaml:"trash_retention" env-default:"720h"`
+}
+
+type PasswordConfig struct {
+	MaxAttempts int           `yaml:"max_attempts" env-default:"5"`
+	Window      time.Duration `yaml:"window" env-default:"15m"`
+}
+
+type QRConfig struct {
+	LogoPath string `yaml:"logo_path"`
+}
+
+// AliasConfig holds the rules for custom aliases,
+// route names of the service are reserved automatically
+type AliasConfig struct {
+	Charset       string   `yaml:"charset"`
+	MinLength     int      `yaml:"min_length" env-default:"2"`
+	MaxLength     int      `yaml:"max_length" env-default:"64"`
+	Reserved      []string `yaml:"reserved"`
+	BlocklistPath string   `yaml:"blocklist_path"`
+	// Generator is one of random, sequential, hashids, words
+	Generator string `yaml:"generator" env-default:"random"`
+	Length    int    `yaml:"length" env-default:"7"`
+	Salt      string `yaml:"salt" env:"ALIAS_SALT"`
+}
+
+// URLPolicyConfig restricts the targets links may point to,
+// the host of BaseURL is always treated as self
+type URLPolicyConfig struct {
+	Schemes       []string `yaml:"schemes"`
+	DeniedHosts   []string `yaml:"denied_hosts"`
+	DeniedCIDRs   []string `yaml:"denied_cidrs"`
+	SelfHosts     []string `yaml:"self_hosts"`
+	ResolveHosts  bool     `yaml:"resolve_hosts" env-default:"true"`
+	BlocklistPath string   `yaml:"blocklist_path"`
+	// ReloadInterval is how often the blocklist file is checked for changes
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"30s"`
+}
+
+// GeoIPConfig points to the CSV database of "network,country" lines
+// used by country redirect rules, they never match when it is not set
+type GeoIPConfig struct {
+	Path string `yaml:"path" env:"GEOIP_PATH"`
+}
+
+// RedirectConfig sets the status code of links that do not choose their own
+type RedirectConfig struct {
+	// DefaultCode is one of 301, 302, 307, 308
+	DefaultCode int `yaml:"default_code" env-default:"302"`
+	// MaxAge limits how long browsers keep permanent redirects, they miss changes
+	// of the link for that long, so it defaults to a few minutes
+	MaxAge time.Duration `yaml:"max_age" env-default:"5m"`
+}
+
+// DomainsConfig sets how control of custom domains is verified
+type DomainsConfig struct {
+	// Resolver is the address of the DNS server asked for TXT challenges, empty uses the system one
+	Resolver string `yaml:"resolver" env:"DOMAINS_RESOLVER"`
+	// Timeout limits a single DNS or HTTP challenge
+	Timeout time.Duration `yaml:"timeout" env-default:"5s"`
+}
+
 type GRPCConfig struct {
 	Port    int           `yaml:"port"`
 	Timeout time.Duration `yaml:"timeout"`
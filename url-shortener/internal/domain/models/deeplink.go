@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DeepLink opens the app for mobile visitors. AppURI is a custom scheme URI
+// or a universal link, visitors without the app go to the store of their platform
+// or to the target of the link when the store is not set
+type DeepLink struct {
+	AppURI          string `json:"app_uri" validate:"required,uri,max=2048"`
+	IOSStoreURL     string `json:"ios_store_url,omitempty" validate:"omitempty,url,max=2048"`
+	AndroidStoreURL string `json:"android_store_url,omitempty" validate:"omitempty,url,max=2048"`
+}
+
+var ErrAppURIScheme = errors.New("app_uri scheme is not allowed")
+
+// appURI is the only form of app URIs accepted: a plain scheme followed by ://
+var appURI = regexp.MustCompile(`^[a-z][a-z0-9+.-]*://`)
+
+// schemes that run code or read local data instead of opening an app,
+// javascript://%0a... runs code despite the slashes
+var unsafeSchemes = map[string]bool{
+	"javascript":  true,
+	"vbscript":    true,
+	"data":        true,
+	"file":        true,
+	"blob":        true,
+	"filesystem":  true,
+	"about":       true,
+	"view-source": true,
+}
+
+// Validate rejects app URIs the browser would execute instead of handing to an app.
+// The app page puts AppURI into a link without the URL sanitizing of html/template,
+// so only scheme:// URIs of schemes that are not known to be unsafe pass
+func (d DeepLink) Validate() error {
+	if !appURI.MatchString(strings.ToLower(d.AppURI)) {
+		return ErrAppURIScheme
+	}
+
+	u, err := url.Parse(d.AppURI)
+	if err != nil || unsafeSchemes[strings.ToLower(u.Scheme)] {
+		return ErrAppURIScheme
+	}
+
+	return nil
+}
+
+// WebURLs returns the URLs the browser opens itself: the stores and a universal link,
+// they are subject to the same policy as targets
+func (d DeepLink) WebURLs() []string {
+	var urls []string
+
+	if u, err := url.Parse(d.AppURI); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		urls = append(urls, d.AppURI)
+	}
+	for _, store := range []string{d.IOSStoreURL, d.AndroidStoreURL} {
+		if store != "" {
+			urls = append(urls, store)
+		}
+	}
+
+	return urls
+}
+
+// StoreURL returns the store fallback for the platform, empty when it has none
+func (d DeepLink) StoreURL(platform string) string {
+	switch platform {
+	case PlatformIOS:
+		return d.IOSStoreURL
+	case PlatformAndroid:
+		return d.AndroidStoreURL
+	}
+
+	return ""
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeepLinkValidate(t *testing.T) {
+	cases := []struct {
+		appURI  string
+		wantErr bool
+	}{
+		{appURI: "myapp://promo?id=1"},
+		{appURI: "https://example.com/app/promo"},
+		{appURI: "javascript:alert(1)", wantErr: true},
+		{appURI: "JavaScript:alert(1)", wantErr: true},
+		{appURI: "data:text/html,hi", wantErr: true},
+		{appURI: "promo", wantErr: true},
+		{appURI: "javascript://%0aalert(1)", wantErr: true},
+		{appURI: "JAVASCRIPT://x%0aalert(1)", wantErr: true},
+		{appURI: "myapp:promo", wantErr: true},
+		{appURI: " myapp://promo", wantErr: true},
+		{appURI: "view-source://example.com", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		err := DeepLink{AppURI: tc.appURI}.Validate()
+		if tc.wantErr {
+			require.ErrorIs(t, err, ErrAppURIScheme, tc.appURI)
+		} else {
+			require.NoError(t, err, tc.appURI)
+		}
+	}
+}
+
+func TestDeepLinkWebURLs(t *testing.T) {
+	d := DeepLink{
+		AppURI:          "https://example.com/app",
+		AndroidStoreURL: "https://play.google.com/store/apps/details?id=app",
+	}
+	require.Equal(t, []string{d.AppURI, d.AndroidStoreURL}, d.WebURLs())
+
+	d.AppURI = "myapp://home"
+	require.Equal(t, []string{d.AndroidStoreURL}, d.WebURLs())
+}
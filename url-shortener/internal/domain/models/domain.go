@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Domain is a hostname of the user that short links can be served from.
+// Links are only created on it once the user has proven control of the host
+type Domain struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+	Host   string `json:"host"`
+	// Token has to be published in the DNS TXT record or the HTTP file of the challenge
+	Token      string     `json:"token"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	// NotFoundURL receives visitors of aliases missing on the domain, empty answers with 404
+	NotFoundURL string    `json:"not_found_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Verified reports whether the user has passed the challenge of the domain
+func (d Domain) Verified() bool {
+	return d.VerifiedAt != nil
+}
+
+// LinkRef identifies the link among all domains. Aliases of the service domain are their own refs,
+// the ones of custom domains are prefixed with the host, so "go.acme.com/promo".
+// Storage methods that take an alias expect the ref of the link
+func LinkRef(domain, alias string) string {
+	if domain == "" {
+		return alias
+	}
+
+	return domain + "/" + alias
+}
+
+// NormalizeHost lower-cases the host and drops the trailing dot of a fully qualified name
+func NormalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
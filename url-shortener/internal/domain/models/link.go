@@ -0,0 +1,110 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+type Link struct {
+	ID    int64  `json:"id"`
+	Alias string `json:"alias"`
+	// Domain is the custom host the alias belongs to, empty for the domain of the service
+	Domain    string     `json:"domain,omitempty"`
+	URL       string     `json:"url"`
+	UserID    int64      `json:"user_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxClicks *int64     `json:"max_clicks,omitempty"`
+	Clicks    int64      `json:"clicks"`
+	// Protected is set for links that require a password before redirect
+	Protected bool `json:"protected"`
+	// PasswordHash is only filled when the link is saved
+	PasswordHash []byte   `json:"-"`
+	FolderID     *int64   `json:"folder_id,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	// DeletedAt is set while the link is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// RedirectCode is 0 for links that use the default code of the service
+	RedirectCode int `json:"redirect_code,omitempty"`
+	// ForwardQuery merges the query of the visit into the target,
+	// ForwardPath appends the path after the alias to it
+	ForwardQuery bool `json:"forward_query"`
+	ForwardPath  bool `json:"forward_path"`
+	// NotBefore is the launch time of a scheduled link, it does not redirect earlier
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	// Paused links do not redirect until the owner resumes them
+	Paused bool `json:"paused"`
+	// Title and Description are shown on the preview page of the link,
+	// AlwaysPreview shows it instead of redirecting on every visit
+	Title         string `json:"title,omitempty"`
+	Description   string `json:"description,omitempty"`
+	AlwaysPreview bool   `json:"always_preview"`
+	// DeepLink is set for links that open the app on mobile visitors
+	DeepLink *DeepLink `json:"deep_link,omitempty"`
+	// Page is set for link-in-bio pages, their URL is the address of the page itself
+	Page *Page `json:"page,omitempty"`
+	// UTM is read from the target, it is nil when the target has no campaign parameters
+	UTM *UTM `json:"utm,omitempty"`
+	// Rules and Variants are only loaded with a single link, not in listings
+	Rules    []Rule    `json:"rules,omitempty"`
+	Variants []Variant `json:"variants,omitempty"`
+}
+
+// Ref returns the alias prefixed with the custom domain of the link, see LinkRef
+func (l Link) Ref() string {
+	return LinkRef(l.Domain, l.Alias)
+}
+
+// Expired reports whether the expiration date of the link has passed
+func (l Link) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// Scheduled reports whether the launch time of the link is still ahead
+func (l Link) Scheduled(now time.Time) bool {
+	return l.NotBefore != nil && now.Before(*l.NotBefore)
+}
+
+// ValidRedirectCode reports whether links may redirect with the status code
+func ValidRedirectCode(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+
+	return false
+}
+
+// Exhausted reports whether the link has used up all of its clicks
+func (l Link) Exhausted() bool {
+	return l.MaxClicks != nil && l.Clicks >= *l.MaxClicks
+}
+
+// LinkCursor points to the last link of the previous page
+type LinkCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// LinkFilter describes which links of the user should be listed
+type LinkFilter struct {
+	UserID      int64
+	AliasPrefix string
+	// Domain is the custom domain the short links are served on
+	Domain string
+	// TargetHost matches the host of the target URL and its subdomains
+	TargetHost string
+	Tag        string
+	FolderID   *int64
+	Desc       bool
+	Limit      int
+	After      *LinkCursor
+}
+
+// Revision keeps the target the link pointed to before an update
+type Revision struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	ChangedBy int64     `json:"changed_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// Themes of link-in-bio pages
+const (
+	PageThemeLight = "light"
+	PageThemeDark  = "dark"
+)
+
+// Page is a link-in-bio landing page shown at the alias instead of a redirect.
+// Title and Description of the page are the ones of its link
+type Page struct {
+	Theme string `json:"theme"`
+	// Items are only loaded with a single page
+	Items []PageItem `json:"items,omitempty"`
+}
+
+// PageItem is a link of the page owner listed on the page, ordered by Position
+type PageItem struct {
+	Alias    string `json:"alias"`
+	Title    string `json:"title"`
+	Position int    `json:"position"`
+}
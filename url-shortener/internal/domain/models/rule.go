@@ -0,0 +1,26 @@
+package models
+
+// Platforms detected from the User-Agent of a visitor
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformDesktop = "desktop"
+)
+
+// Rule sends visitors matching all of its conditions to its own target.
+// Empty conditions match everyone, rules of a link are evaluated by Position
+type Rule struct {
+	ID       int64  `json:"id"`
+	Position int    `json:"position"`
+	Platform string `json:"platform,omitempty"`
+	// Language is a language tag like "de" or "pt-BR", "de" also matches "de-AT"
+	Language string `json:"language,omitempty"`
+	// Country is ISO 3166-1 alpha-2 code resolved from the visitor IP
+	Country string `json:"country,omitempty"`
+	// TimeFrom and TimeTo are "15:04" clock times, the window may pass midnight
+	TimeFrom string `json:"time_from,omitempty"`
+	TimeTo   string `json:"time_to,omitempty"`
+	// Timezone of the window, UTC when empty
+	Timezone string `json:"timezone,omitempty"`
+	URL      string `json:"url"`
+}
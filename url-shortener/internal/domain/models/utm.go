@@ -0,0 +1,98 @@
+package models
+
+import (
+	"net/url"
+	"time"
+)
+
+// UTM holds campaign parameters of a target URL
+type UTM struct {
+	Source   string `json:"utm_source,omitempty" validate:"omitempty,max=200"`
+	Medium   string `json:"utm_medium,omitempty" validate:"omitempty,max=200"`
+	Campaign string `json:"utm_campaign,omitempty" validate:"omitempty,max=200"`
+	Term     string `json:"utm_term,omitempty" validate:"omitempty,max=200"`
+	Content  string `json:"utm_content,omitempty" validate:"omitempty,max=200"`
+}
+
+// UTMTemplate keeps campaign parameters the user applies to new links,
+// the default one is applied when the link does not choose a template
+type UTMTemplate struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Default   bool      `json:"default"`
+	UTM       UTM       `json:"utm"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Empty reports whether none of the parameters is set
+func (u UTM) Empty() bool {
+	return u == UTM{}
+}
+
+// WithDefaults fills parameters missing in u from defaults
+func (u UTM) WithDefaults(defaults UTM) UTM {
+	fill := func(v *string, d string) {
+		if *v == "" {
+			*v = d
+		}
+	}
+
+	fill(&u.Source, defaults.Source)
+	fill(&u.Medium, defaults.Medium)
+	fill(&u.Campaign, defaults.Campaign)
+	fill(&u.Term, defaults.Term)
+	fill(&u.Content, defaults.Content)
+
+	return u
+}
+
+// Apply sets the parameters on the target, replacing the ones it already has
+func (u UTM) Apply(target string) (string, error) {
+	if u.Empty() {
+		return target, nil
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	q := parsed.Query()
+	for key, value := range u.Fields() {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	parsed.RawQuery = q.Encode()
+
+	return parsed.String(), nil
+}
+
+// ParseUTM reads campaign parameters from the query of the target
+func ParseUTM(target string) UTM {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return UTM{}
+	}
+
+	q := parsed.Query()
+
+	return UTM{
+		Source:   q.Get("utm_source"),
+		Medium:   q.Get("utm_medium"),
+		Campaign: q.Get("utm_campaign"),
+		Term:     q.Get("utm_term"),
+		Content:  q.Get("utm_content"),
+	}
+}
+
+// Fields returns the parameters keyed by their query names
+func (u UTM) Fields() map[string]string {
+	return map[string]string{
+		"utm_source":   u.Source,
+		"utm_medium":   u.Medium,
+		"utm_campaign": u.Campaign,
+		"utm_term":     u.Term,
+		"utm_content":  u.Content,
+	}
+}
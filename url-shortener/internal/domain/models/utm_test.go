@@ -0,0 +1,22 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUTMApply(t *testing.T) {
+	utm := UTM{Source: "newsletter", Medium: "email"}.WithDefaults(UTM{Medium: "social", Campaign: "spring"})
+	require.Equal(t, UTM{Source: "newsletter", Medium: "email", Campaign: "spring"}, utm)
+
+	got, err := utm.Apply("https://example.com/sale?utm_source=old&id=7")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/sale?id=7&utm_campaign=spring&utm_medium=email&utm_source=newsletter", got)
+
+	require.Equal(t, utm, ParseUTM(got))
+
+	got, err = UTM{}.Apply("https://example.com/?b=1&a=2")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/?b=1&a=2", got)
+}
@@ -0,0 +1,10 @@
+package models
+
+// Variant is one of the weighted destinations of an A/B split link.
+// Visitors stick to the variant they got first
+type Variant struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
@@ -0,0 +1,31 @@
+package domainref
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+)
+
+// New rewrites the alias URL parameter to the ref of the link when the request names
+// a custom domain with ?domain=, so handlers of /{alias} routes reach links of every domain.
+// It must run after routing, so it is added with chi With or Group
+func New() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			domain := models.NormalizeHost(r.URL.Query().Get("domain"))
+
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && domain != "" {
+				for i, key := range rctx.URLParams.Keys {
+					if key == "alias" {
+						rctx.URLParams.Values[i] = models.LinkRef(domain, rctx.URLParams.Values[i])
+					}
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
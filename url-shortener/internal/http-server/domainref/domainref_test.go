@@ -0,0 +1,37 @@
+package domainref
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainRef(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "Service domain", path: "/url/promo", want: "promo"},
+		{name: "Custom domain", path: "/url/promo?domain=Go.Acme.com", want: "go.acme.com/promo"},
+		{name: "Empty domain", path: "/url/promo?domain=", want: "promo"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+
+			r := chi.NewRouter()
+			r.With(New()).Get("/url/{alias}", func(w http.ResponseWriter, r *http.Request) {
+				got = chi.URLParam(r, "alias")
+			})
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
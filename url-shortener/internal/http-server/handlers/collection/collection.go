@@ -0,0 +1,226 @@
+package collection
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+// Kind describes named items a user groups links with, like tags or folders
+type Kind[T any] struct {
+	// Op prefixes the operation names of the handlers, e.g. "handlers.tags"
+	Op string
+	// Name is the singular item name used in messages, e.g. "tag"
+	Name string
+
+	ErrExists   error
+	ErrNotFound error
+
+	List   func(userID int64) ([]T, error)
+	Create func(userID int64, name string) (T, error)
+	Rename func(userID, id int64, name string) error
+	Delete func(userID, id int64) error
+
+	// Item and Items build the response bodies of a created item and of the item list
+	Item  func(item *T) any
+	Items func(items []T) any
+}
+
+// List returns items of the user
+func List[T any](log *slog.Logger, kind Kind[T]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			slog.String("op", kind.Op+".List"),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		list, err := kind.List(int64(userID))
+		if err != nil {
+			log.Error("failed to list "+kind.Name+"s", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to list "+kind.Name+"s"))
+			return
+		}
+
+		resp.NewJSON(w, r, http.StatusOK, kind.Items(list))
+	}
+}
+
+// Create adds an item
+func Create[T any](log *slog.Logger, kind Kind[T]) http.HandlerFunc {
+	validate := validator.New()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			slog.String("op", kind.Op+".Create"),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		name, ok := decode(w, r, log, validate)
+		if !ok {
+			return
+		}
+
+		item, err := kind.Create(int64(userID), name)
+		switch {
+		case errors.Is(err, kind.ErrExists):
+			log.Info(kind.Name+" already exists", slog.String("name", name))
+			resp.NewJSON(w, r, http.StatusConflict, resp.Error(kind.Name+" already exists"))
+			return
+		case err != nil:
+			log.Error("failed to create "+kind.Name, sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to create "+kind.Name))
+			return
+		}
+
+		log.Info(kind.Name+" created", slog.String("name", name))
+
+		resp.NewJSON(w, r, http.StatusCreated, kind.Item(&item))
+	}
+}
+
+// Rename changes the name of the item
+func Rename[T any](log *slog.Logger, kind Kind[T]) http.HandlerFunc {
+	validate := validator.New()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			slog.String("op", kind.Op+".Rename"),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		id, ok := parseID(w, r, log, kind.Name)
+		if !ok {
+			return
+		}
+
+		name, ok := decode(w, r, log, validate)
+		if !ok {
+			return
+		}
+
+		err := kind.Rename(int64(userID), id, name)
+		switch {
+		case errors.Is(err, kind.ErrNotFound):
+			log.Info(kind.Name+" not found", slog.Int64("id", id))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error(kind.Name+" not found"))
+			return
+		case errors.Is(err, kind.ErrExists):
+			log.Info(kind.Name+" already exists", slog.String("name", name))
+			resp.NewJSON(w, r, http.StatusConflict, resp.Error(kind.Name+" already exists"))
+			return
+		case err != nil:
+			log.Error("failed to rename "+kind.Name, sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to rename "+kind.Name))
+			return
+		}
+
+		log.Info(kind.Name+" renamed", slog.Int64("id", id))
+		resp.NewJSON(w, r, http.StatusOK, resp.OK())
+	}
+}
+
+// Delete removes the item
+func Delete[T any](log *slog.Logger, kind Kind[T]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			slog.String("op", kind.Op+".Delete"),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		id, ok := parseID(w, r, log, kind.Name)
+		if !ok {
+			return
+		}
+
+		err := kind.Delete(int64(userID), id)
+		switch {
+		case errors.Is(err, kind.ErrNotFound):
+			log.Info(kind.Name+" not found", slog.Int64("id", id))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error(kind.Name+" not found"))
+			return
+		case err != nil:
+			log.Error("failed to delete "+kind.Name, sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to delete "+kind.Name))
+			return
+		}
+
+		log.Info(kind.Name+" deleted", slog.Int64("id", id))
+		resp.NewJSON(w, r, http.StatusOK, resp.OK())
+	}
+}
+
+// parseID reads the item id from the url path
+// and writes the error response when it is invalid
+func parseID(w http.ResponseWriter, r *http.Request, log *slog.Logger, name string) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		log.Error("invalid "+name+" id", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid "+name+" id"))
+		return 0, false
+	}
+
+	return id, true
+}
+
+// decode reads the item name from the request body
+// and writes the error response when it is invalid
+func decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, validate *validator.Validate) (string, bool) {
+	var req Request
+
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request body"))
+		return "", false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+
+	if err := validate.Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+
+		log.Error("invalid request", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+		return "", false
+	}
+
+	return req.Name, true
+}
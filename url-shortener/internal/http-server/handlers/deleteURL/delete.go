@@ -11,17 +11,26 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access"
 	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
 )
 
+//go:generate mockery --name=URLDeleter --dir=. --output=./mocks --filename=url_deleter_mock.go --outpkg=mocks
 type URLDeleter interface {
+	URLOwner(alias string) (int64, error)
 	DeleteURL(alias string) error
 }
 
-func New(log *slog.Logger, delete URLDeleter, producer *kafka.Producer) http.HandlerFunc {
+//go:generate mockery --name=ProducerProvider --dir=. --output=./mocks --filename=producer_provider_mock.go --outpkg=mocks
+type ProducerProvider interface {
+	Publish(ctx context.Context, key string, value interface{}) error
+	Close() error
+}
+
+func New(log *slog.Logger, delete URLDeleter, adminChecker access.AdminChecker, producer ProducerProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.deleteURL.deleteURL"
 
@@ -48,18 +57,35 @@ func New(log *slog.Logger, delete URLDeleter, producer *kafka.Producer) http.Han
 			return
 		}
 
+		ownerID, err := delete.URLOwner(alias)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to get alias owner", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+			return
+		}
+
+		if !access.Allow(w, r, log, adminChecker, ownerID, userID, alias) {
+			return
+		}
+
 		ev := map[string]interface{}{
 			"type":      kafka.EventLinkDeleted,
 			"timestamp": time.Now().UTC(),
 			"user_id":   int64(userID),
+			"alias":     alias,
 			"ip":        "kafka:9092",
 		}
 
-		err := delete.DeleteURL(alias)
+		err = delete.DeleteURL(alias)
 
 		switch {
 		case err == nil:
-			log.Info("url deleted")
+			log.Info("url moved to trash")
 			err = producer.Publish(ctx, strconv.FormatInt(int64(userID), 10), ev)
 			if err != nil {
 				log.Error("failed to send message to Kafka", sl.Err(err))
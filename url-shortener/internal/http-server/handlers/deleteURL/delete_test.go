@@ -0,0 +1,122 @@
+package deleteURL
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/deleteURL/mocks"
+	accessmocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access/mocks"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 7
+
+func TestDeleteHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		alias      string
+		ownerID    int64
+		ownerError error
+		checkAdmin bool
+		isAdmin    bool
+		adminError error
+		deleted    bool
+		respError  string
+		wantCode   int
+	}{
+		{
+			name:     "Owner deletes link",
+			alias:    "google",
+			ownerID:  userID,
+			deleted:  true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "Alias not found",
+			alias:      "missing",
+			ownerError: storage.ErrAliasNotFound,
+			respError:  "alias not found",
+			wantCode:   http.StatusNotFound,
+		},
+		{
+			name:       "Not owner",
+			alias:      "google",
+			ownerID:    userID + 1,
+			checkAdmin: true,
+			respError:  "forbidden",
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "Admin deletes someone else's link",
+			alias:      "google",
+			ownerID:    userID + 1,
+			checkAdmin: true,
+			isAdmin:    true,
+			deleted:    true,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "Admin check error",
+			alias:      "google",
+			ownerID:    userID + 1,
+			checkAdmin: true,
+			adminError: errors.New("sso unavailable"),
+			respError:  "unexpected error",
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deleterMock := mocks.NewURLDeleter(t)
+			adminMock := accessmocks.NewAdminChecker(t)
+			producerMock := mocks.NewProducerProvider(t)
+
+			deleterMock.On("URLOwner", tc.alias).
+				Return(tc.ownerID, tc.ownerError).
+				Once()
+
+			if tc.checkAdmin {
+				adminMock.On("IsAdmin", mock.Anything, int64(userID)).
+					Return(tc.isAdmin, tc.adminError).
+					Once()
+			}
+
+			if tc.deleted {
+				deleterMock.On("DeleteURL", tc.alias).
+					Return(nil).
+					Once()
+				producerMock.On("Publish", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+					Return(nil).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Delete("/url/{alias}", New(slogdiscard.NewDiscardLogger(), deleterMock, adminMock, producerMock))
+
+			req := httptest.NewRequest(http.MethodDelete, "/url/"+tc.alias, nil)
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var body resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			require.Equal(t, tc.respError, body.Error)
+		})
+	}
+}
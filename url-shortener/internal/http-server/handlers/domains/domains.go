@@ -0,0 +1,375 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/domainverify"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+type CreateRequest struct {
+	Host string `json:"host" validate:"required,fqdn,max=253"`
+	// NotFoundURL receives visitors of aliases missing on the domain
+	NotFoundURL string `json:"not_found_url,omitempty" validate:"omitempty,url"`
+}
+
+type UpdateRequest struct {
+	NotFoundURL string `json:"not_found_url" validate:"omitempty,url"`
+}
+
+type VerifyRequest struct {
+	Method string `json:"method" validate:"required,oneof=dns http"`
+}
+
+type Response struct {
+	resp.Response
+	Domain *models.Domain `json:"domain,omitempty"`
+	// Challenge is returned with a new domain
+	Challenge *domainverify.Challenge `json:"challenge,omitempty"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Domains []models.Domain `json:"domains"`
+}
+
+//go:generate mockery --name=DomainStorage --dir=. --output=./mocks --filename=domain_storage_mock.go --outpkg=mocks
+type DomainStorage interface {
+	Domains(userID int64) ([]models.Domain, error)
+	UserDomain(userID, domainID int64) (models.Domain, error)
+	CreateDomain(userID int64, d models.Domain) (models.Domain, error)
+	VerifyDomain(userID, domainID int64, at time.Time) (models.Domain, error)
+	SetDomainNotFoundURL(userID, domainID int64, url string) (models.Domain, error)
+	DeleteDomain(userID, domainID int64) (models.Domain, error)
+}
+
+// Verifier runs the challenge proving that the user controls the domain
+//
+//go:generate mockery --name=Verifier --dir=. --output=./mocks --filename=verifier_mock.go --outpkg=mocks
+type Verifier interface {
+	Verify(ctx context.Context, method string, d models.Domain) error
+}
+
+// List returns domains of the user
+func List(log *slog.Logger, domainStorage DomainStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domains.List"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		list, err := domainStorage.Domains(int64(userID))
+		if err != nil {
+			log.Error("failed to list domains", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to list domains"))
+			return
+		}
+
+		resp.NewJSON(w, r, http.StatusOK, ListResponse{
+			Response: resp.OK(),
+			Domains:  list,
+		})
+	}
+}
+
+// Create adds a domain to the user and returns the challenge that verifies it,
+// links can only be created on the domain after the verification
+func Create(log *slog.Logger, domainStorage DomainStorage, checker urlpolicy.Checker) http.HandlerFunc {
+	validate := validator.New()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domains.Create"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		var req CreateRequest
+		if !decode(w, r, log, validate, &req) {
+			return
+		}
+
+		if !checkNotFoundURL(w, r, log, checker, req.NotFoundURL) {
+			return
+		}
+
+		token, err := domainverify.NewToken()
+		if err != nil {
+			log.Error("failed to make token", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to create domain"))
+			return
+		}
+
+		created, err := domainStorage.CreateDomain(int64(userID), models.Domain{
+			Host:        models.NormalizeHost(req.Host),
+			Token:       token,
+			NotFoundURL: req.NotFoundURL,
+		})
+		switch {
+		case errors.Is(err, storage.ErrDomainExists):
+			log.Info("domain already exists", slog.String("host", req.Host))
+			resp.NewJSON(w, r, http.StatusConflict, resp.Error("domain already exists"))
+			return
+		case err != nil:
+			log.Error("failed to create domain", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to create domain"))
+			return
+		}
+
+		log.Info("domain created", slog.Int64("id", created.ID))
+
+		challenge := domainverify.ChallengeOf(created)
+
+		resp.NewJSON(w, r, http.StatusCreated, Response{
+			Response:  resp.OK(),
+			Domain:    &created,
+			Challenge: &challenge,
+		})
+	}
+}
+
+// Verify runs the challenge of the chosen method and marks the domain as verified when it passes
+func Verify(log *slog.Logger, domainStorage DomainStorage, verifier Verifier) http.HandlerFunc {
+	validate := validator.New()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domains.Verify"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		domainID, ok := parseID(w, r, log)
+		if !ok {
+			return
+		}
+
+		var req VerifyRequest
+		if !decode(w, r, log, validate, &req) {
+			return
+		}
+
+		d, err := domainStorage.UserDomain(int64(userID), domainID)
+		switch {
+		case errors.Is(err, storage.ErrDomainNotFound):
+			log.Info("domain not found", slog.Int64("id", domainID))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("domain not found"))
+			return
+		case err != nil:
+			log.Error("failed to get domain", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to verify domain"))
+			return
+		}
+
+		if !d.Verified() {
+			if err := verifier.Verify(r.Context(), req.Method, d); err != nil {
+				// the reason stays in the log, it may describe hosts of the internal network
+				log.Info("domain not verified", slog.String("host", d.Host), sl.Err(err))
+				resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("domain verification failed"))
+				return
+			}
+
+			verified, err := domainStorage.VerifyDomain(int64(userID), domainID, time.Now().UTC())
+			switch {
+			case errors.Is(err, storage.ErrDomainNotFound):
+				log.Info("domain not found", slog.Int64("id", domainID))
+				resp.NewJSON(w, r, http.StatusNotFound, resp.Error("domain not found"))
+				return
+			case errors.Is(err, storage.ErrDomainTaken):
+				log.Warn("domain is verified by another user", slog.String("host", d.Host))
+				resp.NewJSON(w, r, http.StatusConflict, resp.Error("domain is verified by another user"))
+				return
+			case err != nil:
+				log.Error("failed to verify domain", sl.Err(err))
+				resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to verify domain"))
+				return
+			}
+
+			log.Info("domain verified", slog.Int64("id", domainID), slog.String("method", req.Method))
+			d = verified
+		}
+
+		resp.NewJSON(w, r, http.StatusOK, Response{
+			Response: resp.OK(),
+			Domain:   &d,
+		})
+	}
+}
+
+// Update changes the fallback of missing aliases of the domain, an empty URL answers them with 404
+func Update(log *slog.Logger, domainStorage DomainStorage, checker urlpolicy.Checker) http.HandlerFunc {
+	validate := validator.New()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domains.Update"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		domainID, ok := parseID(w, r, log)
+		if !ok {
+			return
+		}
+
+		var req UpdateRequest
+		if !decode(w, r, log, validate, &req) {
+			return
+		}
+
+		if !checkNotFoundURL(w, r, log, checker, req.NotFoundURL) {
+			return
+		}
+
+		d, err := domainStorage.SetDomainNotFoundURL(int64(userID), domainID, req.NotFoundURL)
+		switch {
+		case errors.Is(err, storage.ErrDomainNotFound):
+			log.Info("domain not found", slog.Int64("id", domainID))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("domain not found"))
+			return
+		case err != nil:
+			log.Error("failed to update domain", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to update domain"))
+			return
+		}
+
+		log.Info("domain updated", slog.Int64("id", domainID))
+
+		resp.NewJSON(w, r, http.StatusOK, Response{
+			Response: resp.OK(),
+			Domain:   &d,
+		})
+	}
+}
+
+// Delete removes the domain of the user, domains that still have links are kept
+func Delete(log *slog.Logger, domainStorage DomainStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domains.Delete"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		domainID, ok := parseID(w, r, log)
+		if !ok {
+			return
+		}
+
+		_, err := domainStorage.DeleteDomain(int64(userID), domainID)
+		switch {
+		case errors.Is(err, storage.ErrDomainNotFound):
+			log.Info("domain not found", slog.Int64("id", domainID))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("domain not found"))
+			return
+		case errors.Is(err, storage.ErrDomainInUse):
+			log.Info("domain has links", slog.Int64("id", domainID))
+			resp.NewJSON(w, r, http.StatusConflict, resp.Error("domain has links"))
+			return
+		case err != nil:
+			log.Error("failed to delete domain", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to delete domain"))
+			return
+		}
+
+		log.Info("domain deleted", slog.Int64("id", domainID))
+		resp.NewJSON(w, r, http.StatusOK, resp.OK())
+	}
+}
+
+// decode reads and validates the request body
+// and writes the error response when it is invalid
+func decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, validate *validator.Validate, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request body"))
+		return false
+	}
+
+	if err := validate.Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+
+		log.Error("invalid request", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+		return false
+	}
+
+	return true
+}
+
+// checkNotFoundURL writes the error response when the fallback URL is not allowed
+func checkNotFoundURL(w http.ResponseWriter, r *http.Request, log *slog.Logger, checker urlpolicy.Checker, rawURL string) bool {
+	if rawURL == "" {
+		return true
+	}
+
+	if err := checker.Check(r.Context(), rawURL); err != nil {
+		log.Warn("not found URL rejected", slog.String("url", rawURL), sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+		return false
+	}
+
+	return true
+}
+
+func parseID(w http.ResponseWriter, r *http.Request, log *slog.Logger) (int64, bool) {
+	domainID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		log.Error("invalid domain id", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid domain id"))
+		return 0, false
+	}
+
+	return domainID, true
+}
@@ -0,0 +1,216 @@
+package domains
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/domains/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/domainverify"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	policymocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 8
+
+func TestCreateHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		mockError error
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Success",
+			body:     `{"host":"Go.Acme.com.","not_found_url":"https://acme.com/404"}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:      "Not a hostname",
+			body:      `{"host":"acme"}`,
+			respError: "field Host is not a valid hostname",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Duplicate",
+			body:      `{"host":"go.acme.com"}`,
+			mockError: storage.ErrDomainExists,
+			respError: "domain already exists",
+			wantCode:  http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewDomainStorage(t)
+			checkerMock := policymocks.NewChecker(t)
+
+			if tc.respError == "" || tc.mockError != nil {
+				storageMock.On("CreateDomain", int64(userID), mock.MatchedBy(func(d models.Domain) bool {
+					return d.Host == "go.acme.com" && len(d.Token) == 32
+				})).
+					Return(func(_ int64, d models.Domain) models.Domain { return d }, tc.mockError).
+					Once()
+			}
+			if strings.Contains(tc.body, "not_found_url") {
+				checkerMock.On("Check", mock.Anything, "https://acme.com/404").Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/domains", Create(slogdiscard.NewDiscardLogger(), storageMock, checkerMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/domains", strings.NewReader(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+
+			if tc.wantCode == http.StatusCreated {
+				require.Equal(t, "_shortener-challenge.go.acme.com", got.Challenge.DNSName)
+				require.Equal(t, "shortener-verification="+got.Domain.Token, got.Challenge.DNSValue)
+			}
+		})
+	}
+}
+
+func TestVerifyHandler(t *testing.T) {
+	verifiedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	pending := models.Domain{ID: 5, UserID: userID, Host: "go.acme.com", Token: "secret"}
+	verified := pending
+	verified.VerifiedAt = &verifiedAt
+
+	cases := []struct {
+		name        string
+		body        string
+		domain      models.Domain
+		domainError error
+		verifyError error
+		storeError  error
+		respError   string
+		wantCode    int
+	}{
+		{
+			name:     "Verified",
+			body:     `{"method":"dns"}`,
+			domain:   pending,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Already verified",
+			body:     `{"method":"http"}`,
+			domain:   verified,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Unknown method",
+			body:      `{"method":"email"}`,
+			respError: "field Method must be one of dns http",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:        "Not found",
+			body:        `{"method":"dns"}`,
+			domainError: storage.ErrDomainNotFound,
+			respError:   "domain not found",
+			wantCode:    http.StatusNotFound,
+		},
+		{
+			name:        "Token missing",
+			body:        `{"method":"dns"}`,
+			domain:      pending,
+			verifyError: fmt.Errorf("%w: dial tcp 10.0.0.5:80: connection refused", domainverify.ErrNotVerified),
+			respError:   "domain verification failed",
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:       "Taken",
+			body:       `{"method":"http"}`,
+			domain:     pending,
+			storeError: storage.ErrDomainTaken,
+			respError:  "domain is verified by another user",
+			wantCode:   http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewDomainStorage(t)
+			verifierMock := mocks.NewVerifier(t)
+
+			if !strings.Contains(tc.body, "email") {
+				storageMock.On("UserDomain", int64(userID), int64(5)).Return(tc.domain, tc.domainError).Once()
+			}
+			if tc.domainError == nil && tc.domain.ID != 0 && !tc.domain.Verified() {
+				var method VerifyRequest
+				require.NoError(t, json.Unmarshal([]byte(tc.body), &method))
+
+				verifierMock.On("Verify", mock.Anything, method.Method, tc.domain).Return(tc.verifyError).Once()
+			}
+			if tc.verifyError == nil && tc.domain.ID != 0 && !tc.domain.Verified() {
+				storageMock.On("VerifyDomain", int64(userID), int64(5), mock.AnythingOfType("time.Time")).
+					Return(verified, tc.storeError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/domains/{id}/verify", Verify(slogdiscard.NewDiscardLogger(), storageMock, verifierMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/domains/5/verify", strings.NewReader(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+
+			if tc.wantCode == http.StatusOK {
+				require.True(t, got.Domain.Verified())
+			}
+		})
+	}
+}
+
+func TestDeleteHandler(t *testing.T) {
+	storageMock := mocks.NewDomainStorage(t)
+	storageMock.On("DeleteDomain", int64(userID), int64(5)).
+		Return(models.Domain{}, storage.ErrDomainInUse).
+		Once()
+
+	r := chi.NewRouter()
+	r.Delete("/domains/{id}", Delete(slogdiscard.NewDiscardLogger(), storageMock))
+
+	req := httptest.NewRequest(http.MethodDelete, "/domains/5", nil)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code)
+}
@@ -0,0 +1,68 @@
+package folders
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/collection"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Folder *models.Folder `json:"folder,omitempty"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Folders []models.Folder `json:"folders"`
+}
+
+//go:generate mockery --name=FolderStorage --dir=. --output=./mocks --filename=folder_storage_mock.go --outpkg=mocks
+type FolderStorage interface {
+	Folders(userID int64) ([]models.Folder, error)
+	CreateFolder(userID int64, name string) (models.Folder, error)
+	RenameFolder(userID, folderID int64, name string) error
+	DeleteFolder(userID, folderID int64) error
+}
+
+// List returns folders of the user
+func List(log *slog.Logger, folderStorage FolderStorage) http.HandlerFunc {
+	return collection.List(log, kind(folderStorage))
+}
+
+// Create adds an empty folder
+func Create(log *slog.Logger, folderStorage FolderStorage) http.HandlerFunc {
+	return collection.Create(log, kind(folderStorage))
+}
+
+// Rename changes the name of the folder
+func Rename(log *slog.Logger, folderStorage FolderStorage) http.HandlerFunc {
+	return collection.Rename(log, kind(folderStorage))
+}
+
+// Delete removes the folder, its links stay without a folder
+func Delete(log *slog.Logger, folderStorage FolderStorage) http.HandlerFunc {
+	return collection.Delete(log, kind(folderStorage))
+}
+
+func kind(folderStorage FolderStorage) collection.Kind[models.Folder] {
+	return collection.Kind[models.Folder]{
+		Op:          "handlers.folders",
+		Name:        "folder",
+		ErrExists:   storage.ErrFolderExists,
+		ErrNotFound: storage.ErrFolderNotFound,
+		List:        folderStorage.Folders,
+		Create:      folderStorage.CreateFolder,
+		Rename:      folderStorage.RenameFolder,
+		Delete:      folderStorage.DeleteFolder,
+		Item: func(folder *models.Folder) any {
+			return Response{Response: resp.OK(), Folder: folder}
+		},
+		Items: func(folders []models.Folder) any {
+			return ListResponse{Response: resp.OK(), Folders: folders}
+		},
+	}
+}
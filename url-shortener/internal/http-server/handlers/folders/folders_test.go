@@ -0,0 +1,72 @@
+package folders
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/folders/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 4
+
+func TestCreateHandler(t *testing.T) {
+	storageMock := mocks.NewFolderStorage(t)
+	storageMock.On("CreateFolder", int64(userID), "Campaigns").
+		Return(models.Folder{}, storage.ErrFolderExists).Once()
+
+	r := chi.NewRouter()
+	r.Post("/folders", Create(slogdiscard.NewDiscardLogger(), storageMock))
+
+	req := httptest.NewRequest(http.MethodPost, "/folders", strings.NewReader(`{"name":"Campaigns"}`))
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code)
+
+	var got Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, "folder already exists", got.Error)
+}
+
+func TestDeleteHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		mockError error
+		wantCode  int
+	}{
+		{name: "Success", wantCode: http.StatusOK},
+		{name: "Not found", mockError: storage.ErrFolderNotFound, wantCode: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewFolderStorage(t)
+			storageMock.On("DeleteFolder", int64(userID), int64(3)).Return(tc.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Delete("/folders/{id}", Delete(slogdiscard.NewDiscardLogger(), storageMock))
+
+			req := httptest.NewRequest(http.MethodDelete, "/folders/3", nil)
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package pages
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/alias"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+// Request describes the page, items are shown in the order of the list
+type Request struct {
+	Title       string `json:"title" validate:"required,max=200"`
+	Description string `json:"description,omitempty" validate:"max=1000"`
+	Theme       string `json:"theme,omitempty" validate:"omitempty,oneof=light dark"`
+	Items       []Item `json:"items" validate:"max=50,unique=Alias,dive"`
+}
+
+// CreateRequest is Request with the alias the page is published at
+type CreateRequest struct {
+	Alias string `json:"alias" validate:"required,alias"`
+	Request
+}
+
+// Item is a link of the page owner, the alias is shown when the title is empty
+type Item struct {
+	Alias string `json:"alias" validate:"required"`
+	Title string `json:"title,omitempty" validate:"max=200"`
+}
+
+type Response struct {
+	resp.Response
+	Page *models.Link `json:"page,omitempty"`
+}
+
+//go:generate mockery --name=PageStorage --dir=. --output=./mocks --filename=page_storage_mock.go --outpkg=mocks
+type PageStorage interface {
+	SaveURL(link models.Link) (int64, error)
+	Page(alias string) (models.Link, error)
+	UpdatePage(alias, title, description string, page models.Page) error
+}
+
+//go:generate mockery --name=ProducerProvider --dir=. --output=./mocks --filename=producer_provider_mock.go --outpkg=mocks
+type ProducerProvider interface {
+	Publish(ctx context.Context, key string, value interface{}) error
+	Close() error
+}
+
+// Create publishes a link-in-bio page at the alias. The page is a link of the user
+// pointing to itself, so it is listed, moved to the trash and restored like other links
+func Create(
+	log *slog.Logger,
+	pageStorage PageStorage,
+	producer ProducerProvider,
+	aliases *alias.Validator,
+	baseURL string,
+) http.HandlerFunc {
+	validate := validator.New()
+	aliases.Register(validate)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.pages.Create"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		var req CreateRequest
+		if !decode(w, r, log, validate, &req) {
+			return
+		}
+
+		link := models.Link{
+			Alias:       req.Alias,
+			URL:         strings.TrimRight(baseURL, "/") + "/" + req.Alias,
+			UserID:      int64(userID),
+			Title:       strings.TrimSpace(req.Title),
+			Description: strings.TrimSpace(req.Description),
+			Page:        page(req.Request),
+		}
+
+		id, err := pageStorage.SaveURL(link)
+		if err != nil {
+			saveError(w, r, log, err, "failed to create page")
+			return
+		}
+
+		ev := map[string]interface{}{
+			"type":      kafka.EventLinkSaved,
+			"timestamp": time.Now().UTC(),
+			"user_id":   userID,
+			"alias":     link.Alias,
+			"url":       link.URL,
+			"link_id":   id,
+			"page":      true,
+		}
+
+		if err := producer.Publish(context.Background(), strconv.FormatInt(int64(userID), 10), ev); err != nil {
+			log.Error("failed to send message to Kafka", sl.Err(err))
+		}
+
+		log.Info("page created", slog.Int64("id", id), slog.Int("items", len(link.Page.Items)))
+		resp.NewJSON(w, r, http.StatusCreated, resp.AliasResponse{Response: resp.OK(), Alias: link.Alias})
+	}
+}
+
+// Get returns the page of the alias with all of its items
+func Get(log *slog.Logger, pageStorage PageStorage, adminChecker access.AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.pages.Get"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		link, ok := managedPage(w, r, log, pageStorage, adminChecker)
+		if !ok {
+			return
+		}
+
+		resp.NewJSON(w, r, http.StatusOK, Response{Response: resp.OK(), Page: &link})
+	}
+}
+
+// Update changes the page of the alias and replaces its items
+func Update(log *slog.Logger, pageStorage PageStorage, adminChecker access.AdminChecker) http.HandlerFunc {
+	validate := validator.New()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.pages.Update"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		link, ok := managedPage(w, r, log, pageStorage, adminChecker)
+		if !ok {
+			return
+		}
+
+		var req Request
+		if !decode(w, r, log, validate, &req) {
+			return
+		}
+
+		err := pageStorage.UpdatePage(link.Alias, strings.TrimSpace(req.Title), strings.TrimSpace(req.Description), *page(req))
+		if err != nil {
+			saveError(w, r, log, err, "failed to update page")
+			return
+		}
+
+		log.Info("page updated", slog.String("alias", link.Alias), slog.Int("items", len(req.Items)))
+		resp.RespOk(w, r, link.Alias)
+	}
+}
+
+// page builds the page of the request, items are positioned in the order they came in
+func page(req Request) *models.Page {
+	p := &models.Page{Theme: req.Theme, Items: make([]models.PageItem, 0, len(req.Items))}
+	if p.Theme == "" {
+		p.Theme = models.PageThemeLight
+	}
+
+	for i, item := range req.Items {
+		p.Items = append(p.Items, models.PageItem{
+			Alias:    item.Alias,
+			Title:    strings.TrimSpace(item.Title),
+			Position: i,
+		})
+	}
+
+	return p
+}
+
+// saveError writes the response for a failed save of the page
+func saveError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error, msg string) {
+	switch {
+	case errors.Is(err, storage.ErrAliasExists):
+		log.Error("alias already exists", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusConflict, resp.Error("alias already exists"))
+	case errors.Is(err, storage.ErrPageNotFound):
+		log.Error("page not found", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusNotFound, resp.Error("page not found"))
+	case errors.Is(err, storage.ErrPageItemNotFound):
+		// the message names the alias that is missing
+		log.Error("page item not found", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+	default:
+		log.Error(msg, sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error(msg))
+	}
+}
+
+// decode reads and validates the JSON body into req
+// and writes the error response when it is invalid
+func decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, validate *validator.Validate, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request body"))
+		return false
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	if err := validate.Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+
+		log.Error("invalid request", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+		return false
+	}
+
+	return true
+}
+
+// managedPage loads the page from the url path and checks that the caller may manage it,
+// writing the error response otherwise
+func managedPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	pageStorage PageStorage,
+	adminChecker access.AdminChecker,
+) (models.Link, bool) {
+	userID, ok := mdjwt.GetUserID(r.Context())
+	if !ok {
+		resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+		return models.Link{}, false
+	}
+
+	link, err := pageStorage.Page(chi.URLParam(r, "alias"))
+	switch {
+	case errors.Is(err, storage.ErrPageNotFound):
+		log.Error("page not found", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusNotFound, resp.Error("page not found"))
+		return models.Link{}, false
+	case err != nil:
+		log.Error("failed to get page", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+		return models.Link{}, false
+	}
+
+	if !access.Allow(w, r, log, adminChecker, link.UserID, userID, link.Alias) {
+		return models.Link{}, false
+	}
+
+	return link, true
+}
@@ -0,0 +1,168 @@
+package pages
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/pages/mocks"
+	accessmocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/alias"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 7
+
+func TestCreateHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		mockError error
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Success",
+			body:     `{"alias":"me","title":"My links","theme":"dark","items":[{"alias":"blog","title":"Blog"},{"alias":"shop"}]}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:      "Missing title",
+			body:      `{"alias":"me","items":[]}`,
+			respError: "field Title is a required field",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Unknown theme",
+			body:      `{"alias":"me","title":"My links","theme":"neon"}`,
+			respError: "field Theme must be one of light dark",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Item of another user",
+			body:      `{"alias":"me","title":"My links","theme":"dark","items":[{"alias":"blog","title":"Blog"},{"alias":"shop"}]}`,
+			mockError: fmt.Errorf("%w: shop", storage.ErrPageItemNotFound),
+			respError: "page item not found: shop",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Alias taken",
+			body:      `{"alias":"me","title":"My links","theme":"dark","items":[{"alias":"blog","title":"Blog"},{"alias":"shop"}]}`,
+			mockError: storage.ErrAliasExists,
+			respError: "alias already exists",
+			wantCode:  http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewPageStorage(t)
+			producerMock := mocks.NewProducerProvider(t)
+
+			if tc.respError == "" || tc.mockError != nil {
+				storageMock.On("SaveURL", mock.MatchedBy(func(link models.Link) bool {
+					return link.Alias == "me" && link.URL == "https://sho.rt/me" && link.UserID == userID &&
+						link.Page != nil && link.Page.Theme == models.PageThemeDark &&
+						len(link.Page.Items) == 2 && link.Page.Items[1].Alias == "shop" && link.Page.Items[1].Position == 1
+				})).
+					Return(int64(3), tc.mockError).
+					Once()
+			}
+
+			if tc.respError == "" {
+				producerMock.On("Publish", mock.Anything, "7", mock.MatchedBy(func(ev map[string]interface{}) bool {
+					return ev["type"] == kafka.EventLinkSaved && ev["page"] == true
+				})).
+					Return(nil).
+					Once()
+			}
+
+			aliases, err := alias.NewValidator(alias.Options{MinLength: 2, MaxLength: 64})
+			require.NoError(t, err)
+
+			r := chi.NewRouter()
+			r.Post("/pages", Create(slogdiscard.NewDiscardLogger(), storageMock, producerMock, aliases, "https://sho.rt/"))
+
+			req := httptest.NewRequest(http.MethodPost, "/pages", strings.NewReader(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+func TestUpdateHandler(t *testing.T) {
+	cases := []struct {
+		name     string
+		ownerID  int64
+		wantCode int
+	}{
+		{
+			name:     "Owner",
+			ownerID:  userID,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Someone else",
+			ownerID:  99,
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewPageStorage(t)
+			adminMock := accessmocks.NewAdminChecker(t)
+
+			storageMock.On("Page", "me").
+				Return(models.Link{ID: 3, Alias: "me", UserID: tc.ownerID, Page: &models.Page{Theme: models.PageThemeLight}}, nil).
+				Once()
+
+			if tc.wantCode == http.StatusOK {
+				storageMock.On("UpdatePage", "me", "Links", "", models.Page{
+					Theme: models.PageThemeLight,
+					Items: []models.PageItem{{Alias: "blog", Position: 0}},
+				}).
+					Return(nil).
+					Once()
+			} else {
+				adminMock.On("IsAdmin", mock.Anything, int64(userID)).Return(false, nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Put("/pages/{alias}", Update(slogdiscard.NewDiscardLogger(), storageMock, adminMock))
+
+			req := httptest.NewRequest(http.MethodPut, "/pages/me", strings.NewReader(`{"title":"Links","items":[{"alias":"blog"}]}`))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+		})
+	}
+}
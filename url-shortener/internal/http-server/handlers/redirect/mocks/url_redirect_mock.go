@@ -2,26 +2,39 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	models "github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // URLSearcher is an autogenerated mock type for the URLSearcher type
 type URLSearcher struct {
 	mock.Mock
 }
 
-// GetUrl provides a mock function with given fields: alias
-func (_m *URLSearcher) GetUrl(alias string) (string, error) {
+// ConsumeClick provides a mock function with given fields: link
+func (_m *URLSearcher) ConsumeClick(link models.Link) error {
+	ret := _m.Called(link)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Link) error); ok {
+		r0 = rf(link)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetLink provides a mock function with given fields: alias
+func (_m *URLSearcher) GetLink(alias string) (models.Link, error) {
 	ret := _m.Called(alias)
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	var r0 models.Link
+	if rf, ok := ret.Get(0).(func(string) models.Link); ok {
 		r0 = rf(alias)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(string)
-		} else {
-			r0 = ""
-		}
+		r0 = ret.Get(0).(models.Link)
 	}
 
 	var r1 error
@@ -34,12 +47,58 @@ func (_m *URLSearcher) GetUrl(alias string) (string, error) {
 	return r0, r1
 }
 
+// LinkPasswordHash provides a mock function with given fields: linkID
+func (_m *URLSearcher) LinkPasswordHash(linkID int64) ([]byte, error) {
+	ret := _m.Called(linkID)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(int64) []byte); ok {
+		r0 = rf(linkID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(linkID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PageItems provides a mock function with given fields: pageID
+func (_m *URLSearcher) PageItems(pageID int64) ([]models.PageItem, error) {
+	ret := _m.Called(pageID)
+
+	var r0 []models.PageItem
+	if rf, ok := ret.Get(0).(func(int64) []models.PageItem); ok {
+		r0 = rf(pageID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.PageItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(pageID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewURLSearcher interface {
 	mock.TestingT
 	Cleanup(func())
 }
 
-// NewURLSearcher creates a new instance of URLSearcher. It also registers a testing interface on the mock and a cleanup function to assert the mock's expectations.
+// NewURLSearcher creates a new instance of URLSearcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 func NewURLSearcher(t mockConstructorTestingTNewURLSearcher) *URLSearcher {
 	mock := &URLSearcher{}
 	mock.Mock.Test(t)
@@ -1,24 +1,108 @@
 package redirect
 
 import (
+	"context"
+	"embed"
 	"errors"
+	"fmt"
+	"html/template"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/clicks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
 	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/passthrough"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/rules"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/split"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"golang.org/x/crypto/bcrypt"
 )
 
 //go:generate mockery --name=URLSearcher --dir=. --output=./mocks --filename=url_redirect_mock.go --outpkg=mocks
 type URLSearcher interface {
-	GetUrl(alias string) (string, error)
+	GetLink(alias string) (models.Link, error)
+	ConsumeClick(link models.Link) error
+	LinkPasswordHash(linkID int64) ([]byte, error)
+	PageItems(pageID int64) ([]models.PageItem, error)
 }
 
-func Redirect(log *slog.Logger, searchUrl URLSearcher) http.HandlerFunc {
+//go:generate mockery --name=ClickTracker --dir=. --output=./mocks --filename=click_tracker_mock.go --outpkg=mocks
+type ClickTracker interface {
+	Track(ev clicks.Event)
+}
+
+// AttemptLimiter limits password attempts, Attempt counts one and reports
+// whether the key is over the limit, Reset forgets them after a correct password
+//
+//go:generate mockery --name=AttemptLimiter --dir=. --output=./mocks --filename=attempt_limiter_mock.go --outpkg=mocks
+type AttemptLimiter interface {
+	Attempt(ctx context.Context, key string) (bool, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// DomainResolver finds the verified custom domain serving the host of the request
+//
+//go:generate mockery --name=DomainResolver --dir=. --output=./mocks --filename=domain_resolver_mock.go --outpkg=mocks
+type DomainResolver interface {
+	Domain(host string) (models.Domain, error)
+}
+
+// Domains finds the custom domain of the request, hosts of the service itself
+// and hosts that cannot name a domain are served without a lookup
+type Domains struct {
+	Resolver DomainResolver
+	// ServiceHosts are the hosts of the service, the host of the base URL among them
+	ServiceHosts []string
+}
+
+// Codes configures status codes and caching of redirects
+type Codes struct {
+	// Default is used by links without their own code
+	Default int
+	// MaxAge limits how long clients may keep permanent redirects
+	MaxAge time.Duration
+}
+
+type UnlockRequest struct {
+	Password string `json:"password"`
+}
+
+//go:embed templates
+var templatesFS embed.FS
+
+var (
+	passwordTmpl = template.Must(template.ParseFS(templatesFS, "templates/password.html"))
+	soonTmpl     = template.Must(template.ParseFS(templatesFS, "templates/soon.html"))
+	previewTmpl  = template.Must(template.ParseFS(templatesFS, "templates/preview.html"))
+	appTmpl      = template.Must(template.ParseFS(templatesFS, "templates/app.html"))
+	pageTmpl     = template.Must(template.ParseFS(templatesFS, "templates/page.html"))
+)
+
+// previewSuffix after the alias asks for the preview page instead of the redirect
+const previewSuffix = "+"
+
+// Redirect sends the visitor to the target of the first matching rule of the link
+// or to its default URL. HEAD requests get the same response without counting a click.
+// /{alias}+, ?preview=1 and links with AlwaysPreview show the preview page instead,
+// ?preview=0 skips the page of AlwaysPreview links.
+// The alias is looked up on the custom domain of the Host header, other hosts serve links of the service
+func Redirect(
+	log *slog.Logger,
+	searchUrl URLSearcher,
+	tracker ClickTracker,
+	geo rules.CountryResolver,
+	domains Domains,
+	codes Codes,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.redirect.redirect"
 
@@ -27,30 +111,584 @@ func Redirect(log *slog.Logger, searchUrl URLSearcher) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		alias := chi.URLParam(r, "alias")
-		if strings.Trim(alias, " ") == "" {
-			log.Error("alias is empty")
-			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+		link, ok := findLink(w, r, log, searchUrl, domains)
+		if !ok {
+			return
+		}
+
+		// the path after the alias is only served by links forwarding it
+		if chi.URLParam(r, "*") != "" && !link.ForwardPath {
+			log.Info("path passthrough is off", slog.String("alias", link.Alias))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("URL not found"))
+
+			return
+		}
+
+		// the preview must not reveal targets of protected links
+		if link.Protected {
+			log.Info("password required", slog.String("alias", link.Alias))
+			passwordRequired(w, r, log, link.Alias, http.StatusUnauthorized, "")
+
+			return
+		}
+
+		if link.Page != nil {
+			showPage(w, r, log, searchUrl, tracker, link)
+			return
+		}
+
+		query := r.URL.Query()
+		if strings.HasSuffix(chi.URLParam(r, "alias"), previewSuffix) ||
+			query.Get("preview") == "1" ||
+			link.AlwaysPreview && query.Get("preview") != "0" {
+			log.Info("preview shown", slog.String("alias", link.Alias))
+			preview(w, r, log, link)
 
 			return
 		}
 
-		url, err := searchUrl.GetUrl(alias)
-		if errors.Is(err, storage.ErrURLNotFound) {
-			log.Info("URL not found", slog.String("alias", alias))
+		// the switch is not part of the visit passed on to the target
+		if query.Has("preview") {
+			query.Del("preview")
+			r.URL.RawQuery = query.Encode()
+		}
+
+		code := link.RedirectCode
+		if code == 0 {
+			code = codes.Default
+		}
+
+		follow(w, r, log, searchUrl, tracker, geo, link, code, codes.MaxAge)
+	}
+}
+
+// Unlock checks the password submitted for a protected link and redirects on success
+func Unlock(
+	log *slog.Logger,
+	searchUrl URLSearcher,
+	tracker ClickTracker,
+	limiter AttemptLimiter,
+	geo rules.CountryResolver,
+	domains Domains,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.redirect.Unlock"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		link, ok := findLink(w, r, log, searchUrl, domains)
+		if !ok {
+			return
+		}
+
+		if chi.URLParam(r, "*") != "" && !link.ForwardPath {
+			log.Info("path passthrough is off", slog.String("alias", link.Alias))
 			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("URL not found"))
 
 			return
 		}
+
+		if !link.Protected {
+			follow(w, r, log, searchUrl, tracker, geo, link, http.StatusSeeOther, 0)
+			return
+		}
+
+		key := link.Ref() + ":" + clientIP(r)
+
+		// the attempt is counted before the password is compared,
+		// so parallel guesses cannot slip in under the limit
+		blocked, err := limiter.Attempt(r.Context(), key)
+		if err != nil {
+			log.Error("failed to register attempt", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+
+			return
+		}
+		if blocked {
+			log.Warn("too many password attempts", slog.String("alias", link.Alias))
+			passwordRequired(w, r, log, link.Alias, http.StatusTooManyRequests, "too many attempts, try again later")
+
+			return
+		}
+
+		hash, err := searchUrl.LinkPasswordHash(link.ID)
+		if err != nil {
+			log.Error("failed to get password hash", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+
+			return
+		}
+
+		if err := bcrypt.CompareHashAndPassword(hash, []byte(readPassword(r))); err != nil {
+			log.Info("invalid password", slog.String("alias", link.Alias))
+			passwordRequired(w, r, log, link.Alias, http.StatusUnauthorized, "invalid password")
+
+			return
+		}
+
+		if err := limiter.Reset(r.Context(), key); err != nil {
+			log.Error("failed to reset attempts", sl.Err(err))
+		}
+
+		follow(w, r, log, searchUrl, tracker, geo, link, http.StatusSeeOther, 0)
+	}
+}
+
+// findLink loads the link of the alias from the url path on the domain of the request
+// and writes the error response when it cannot be followed
+func findLink(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	searchUrl URLSearcher,
+	domains Domains,
+) (models.Link, bool) {
+	alias := strings.TrimSuffix(chi.URLParam(r, "alias"), previewSuffix)
+	if strings.Trim(alias, " ") == "" {
+		log.Error("alias is empty")
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+
+		return models.Link{}, false
+	}
+
+	domain, err := requestDomain(r, domains)
+	if err != nil {
+		log.Error("failed to get domain", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+
+		return models.Link{}, false
+	}
+
+	alias = models.LinkRef(domain.Host, alias)
+
+	link, err := searchUrl.GetLink(alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		if domain.NotFoundURL != "" {
+			log.Info("URL not found, sent to the fallback of the domain", slog.String("alias", alias))
+			w.Header().Set("Cache-Control", "no-store")
+			http.Redirect(w, r, domain.NotFoundURL, http.StatusFound)
+
+			return models.Link{}, false
+		}
+
+		log.Info("URL not found", slog.String("alias", alias))
+		resp.NewJSON(w, r, http.StatusNotFound, resp.Error("URL not found"))
+
+		return models.Link{}, false
+	}
+	if err != nil {
+		log.Error("failed searching URL", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+
+		return models.Link{}, false
+	}
+
+	if link.Expired(time.Now()) {
+		log.Info("link expired", slog.String("alias", alias))
+		resp.NewJSON(w, r, http.StatusGone, resp.Error("link expired"))
+
+		return models.Link{}, false
+	}
+
+	if link.Paused {
+		log.Info("link is paused", slog.String("alias", alias))
+		w.Header().Set("Cache-Control", "no-store")
+		resp.NewJSON(w, r, http.StatusNotFound, resp.Error("URL not found"))
+
+		return models.Link{}, false
+	}
+
+	if link.Scheduled(time.Now()) {
+		log.Info("link is not live yet", slog.String("alias", alias))
+		comingSoon(w, r, log, link)
+
+		return models.Link{}, false
+	}
+
+	return link, true
+}
+
+// requestDomain returns the verified domain of the Host header,
+// the zero domain stands for the service itself
+func requestDomain(r *http.Request, domains Domains) (models.Domain, error) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = models.NormalizeHost(host)
+
+	// the Host header is chosen by the client, looking up anything it sends
+	// would fill the cache with not found entries
+	if !validHost(host) {
+		return models.Domain{}, nil
+	}
+	for _, h := range domains.ServiceHosts {
+		if host == models.NormalizeHost(h) {
+			return models.Domain{}, nil
+		}
+	}
+
+	domain, err := domains.Resolver.Domain(host)
+	if errors.Is(err, storage.ErrDomainNotFound) {
+		return models.Domain{}, nil
+	}
+
+	return domain, err
+}
+
+// validHost reports whether host may be a custom domain: dot separated labels of letters,
+// digits and hyphens that do not start or end with a hyphen, and a top level label that is not numeric
+func validHost(host string) bool {
+	if len(host) > 253 {
+		return false
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return false
+	}
+
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+
+	return strings.IndexFunc(labels[len(labels)-1], func(c rune) bool { return c >= 'a' && c <= 'z' }) >= 0
+}
+
+// follow counts the click and redirects to the target of the link
+func follow(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	searchUrl URLSearcher,
+	tracker ClickTracker,
+	geo rules.CountryResolver,
+	link models.Link,
+	code int,
+	maxAge time.Duration,
+) {
+	counted := r.Method != http.MethodHead
+
+	// links with a click limit are counted synchronously to enforce the limit
+	if link.MaxClicks != nil && !counted && link.Exhausted() {
+		log.Info("link has no clicks left", slog.String("alias", link.Alias))
+		resp.NewJSON(w, r, http.StatusGone, resp.Error("link expired"))
+
+		return
+	}
+	if link.MaxClicks != nil && counted {
+		err := searchUrl.ConsumeClick(link)
+		if errors.Is(err, storage.ErrLinkExhausted) {
+			log.Info("link has no clicks left", slog.String("alias", link.Alias))
+			resp.NewJSON(w, r, http.StatusGone, resp.Error("link expired"))
+
+			return
+		}
 		if err != nil {
-			log.Error("failed searching URL", sl.Err(err))
+			log.Error("failed to count click", sl.Err(err))
 			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("internal error"))
 
 			return
 		}
+	}
+
+	target := link.URL
+	ip := clientIP(r)
+
+	var (
+		variant models.Variant
+		matched bool
+	)
+
+	if len(link.Rules) > 0 {
+		var rule models.Rule
+		if rule, matched = rules.Match(link.Rules, rules.NewVisitor(r, ip, geo, time.Now())); matched {
+			log.Info("redirect rule matched", slog.Int64("rule_id", rule.ID))
+			target = rule.URL
+		}
+	}
+
+	// rules send visitors to fixed targets, only the rest takes part in the split
+	if !matched && len(link.Variants) > 0 {
+		var assigned bool
+		if variant, assigned = split.Pick(r, link.ID, link.Variants); variant.URL != "" {
+			target = variant.URL
+			if assigned {
+				http.SetCookie(w, split.Cookie(link.ID, "/"+link.Alias, variant))
+			}
+		}
+	}
+
+	target, err := passthrough.Apply(target, passthrough.Rest(r), r.URL.Query(), link.ForwardPath, link.ForwardQuery)
+	if err != nil {
+		log.Error("failed to forward the visit to the target", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+
+		return
+	}
+
+	log.Info("got url", slog.String("url", target))
+
+	if counted {
+		tracker.Track(clicks.Event{
+			LinkID:    link.ID,
+			Alias:     link.Ref(),
+			Timestamp: time.Now().UTC(),
+			Referrer:  r.Referer(),
+			UserAgent: r.UserAgent(),
+			IP:        ip,
+			RequestID: middleware.GetReqID(r.Context()),
+			Variant:   variant.Name,
+			Tags:      link.Tags,
+			UTM:       models.ParseUTM(target),
+		})
+	}
+
+	// the target depends on the visitor, shared caches must not reuse it
+	personal := len(link.Rules) > 0 || len(link.Variants) > 0 || link.DeepLink != nil
+	if personal {
+		w.Header().Set("Vary", "User-Agent, Accept-Language")
+	}
+
+	if link.DeepLink != nil {
+		if platform := rules.Platform(r.UserAgent()); platform == models.PlatformIOS || platform == models.PlatformAndroid {
+			log.Info("opening app", slog.String("platform", platform))
+			openApp(w, log, *link.DeepLink, platform, target)
+
+			return
+		}
+	}
+	w.Header().Set("Cache-Control", cacheControl(link, code, maxAge, personal, time.Now()))
 
-		log.Info("got url", slog.String("url", url))
+	http.Redirect(w, r, target, code)
+}
 
-		http.Redirect(w, r, url, http.StatusFound)
+// cacheControl lets clients keep permanent redirects for up to maxAge.
+// Temporary redirects and links counting every click must reach the service each time
+func cacheControl(link models.Link, code int, maxAge time.Duration, personal bool, now time.Time) string {
+	permanent := code == http.StatusMovedPermanently || code == http.StatusPermanentRedirect
+	if !permanent || link.MaxClicks != nil || maxAge <= 0 {
+		return "no-store"
+	}
+
+	if link.ExpiresAt != nil {
+		if left := link.ExpiresAt.Sub(now); left < maxAge {
+			maxAge = left
+		}
 	}
+
+	scope := "public"
+	if personal {
+		scope = "private"
+	}
+
+	return fmt.Sprintf("%s, max-age=%d", scope, int(maxAge/time.Second))
+}
+
+// passwordRequired renders the password form for browsers and JSON error for API clients
+func passwordRequired(w http.ResponseWriter, r *http.Request, log *slog.Logger, alias string, code int, msg string) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		if msg == "" {
+			msg = "password required"
+		}
+		resp.NewJSON(w, r, code, resp.Error(msg))
+
+		return
+	}
+
+	// the first visit just shows the form
+	if msg == "" {
+		code = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+
+	// the form is posted back to the visited path, so the forwarded path and query are kept
+	action := visitPath(r, alias)
+	if r.URL.RawQuery != "" {
+		action += "?" + r.URL.RawQuery
+	}
+
+	err := passwordTmpl.Execute(w, struct {
+		Action string
+		Error  string
+	}{Action: action, Error: msg})
+	if err != nil {
+		log.Error("failed to render password form", sl.Err(err))
+	}
+}
+
+// comingSoon answers with 404 before the launch of the link,
+// browsers get a page with the launch time
+func comingSoon(w http.ResponseWriter, r *http.Request, log *slog.Logger, link models.Link) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		resp.NewJSON(w, r, http.StatusNotFound, resp.Error("URL not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+
+	if err := soonTmpl.Execute(w, struct{ NotBefore time.Time }{NotBefore: link.NotBefore.UTC()}); err != nil {
+		log.Error("failed to render coming soon page", sl.Err(err))
+	}
+}
+
+// preview renders the page with the target of the link and a button that follows it
+func preview(w http.ResponseWriter, r *http.Request, log *slog.Logger, link models.Link) {
+	title := link.Title
+	if title == "" {
+		if target, err := url.Parse(link.URL); err == nil {
+			title = target.Hostname()
+		}
+	}
+
+	query := r.URL.Query()
+	query.Set("preview", "0")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	err := previewTmpl.Execute(w, struct {
+		Title       string
+		Description string
+		Target      string
+		Continue    string
+	}{
+		Title:       title,
+		Description: link.Description,
+		Target:      link.URL,
+		Continue:    visitPath(r, link.Alias) + "?" + query.Encode(),
+	})
+	if err != nil {
+		log.Error("failed to render preview page", sl.Err(err))
+	}
+}
+
+// visitPath is the escaped path of the visit without the preview suffix,
+// the path forwarded after the alias is kept
+func visitPath(r *http.Request, alias string) string {
+	p := "/" + url.PathEscape(alias)
+	if rest := passthrough.Rest(r); rest != "" {
+		p += "/" + rest
+	}
+
+	return p
+}
+
+// showPage renders the link-in-bio page. Items lead to their aliases,
+// so following them goes through the redirect and is counted there
+func showPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	searchUrl URLSearcher,
+	tracker ClickTracker,
+	link models.Link,
+) {
+	items, err := searchUrl.PageItems(link.ID)
+	if err != nil {
+		log.Error("failed to get page items", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+
+		return
+	}
+
+	// a view of the page is a visit of its alias
+	if r.Method != http.MethodHead {
+		tracker.Track(clicks.Event{
+			LinkID:    link.ID,
+			Alias:     link.Ref(),
+			Timestamp: time.Now().UTC(),
+			Referrer:  r.Referer(),
+			UserAgent: r.UserAgent(),
+			IP:        clientIP(r),
+			RequestID: middleware.GetReqID(r.Context()),
+			Tags:      link.Tags,
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	err = pageTmpl.Execute(w, struct {
+		Title       string
+		Description string
+		Theme       string
+		Items       []models.PageItem
+	}{
+		Title:       link.Title,
+		Description: link.Description,
+		Theme:       link.Page.Theme,
+		Items:       items,
+	})
+	if err != nil {
+		log.Error("failed to render page", sl.Err(err))
+	}
+}
+
+// openApp renders the page that tries the app URI and goes to the store of the platform,
+// or to the target when the link has no store for it, if the app does not open
+func openApp(w http.ResponseWriter, log *slog.Logger, deepLink models.DeepLink, platform, target string) {
+	fallback := deepLink.StoreURL(platform)
+	if fallback == "" {
+		fallback = target
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	// the scheme of the app URI is checked when the deep link is saved
+	err := appTmpl.Execute(w, struct {
+		AppURI   template.URL
+		Fallback string
+	}{
+		AppURI:   template.URL(deepLink.AppURI),
+		Fallback: fallback,
+	})
+	if err != nil {
+		log.Error("failed to render app page", sl.Err(err))
+	}
+}
+
+// readPassword takes the password from a JSON body or a submitted form
+func readPassword(r *http.Request) string {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var req UnlockRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			return ""
+		}
+
+		return req.Password
+	}
+
+	return r.FormValue("password")
+}
+
+// clientIP strips the port from the remote address,
+// realip has already applied headers of trusted proxies
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
 }
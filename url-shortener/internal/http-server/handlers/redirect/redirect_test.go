@@ -7,17 +7,26 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/clicks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/redirect/mocks"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	rulesmocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/rules/mocks"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
+var testCodes = Codes{Default: http.StatusFound, MaxAge: time.Hour}
+
 func TestRedirectHandler(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -62,20 +71,25 @@ func TestRedirectHandler(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
 
 			if tc.alias != "" {
 				if tc.mockError != nil {
-					urlSearcherMock.On("GetUrl", tc.alias).
-						Return("", tc.mockError).
+					urlSearcherMock.On("GetLink", tc.alias).
+						Return(models.Link{}, tc.mockError).
 						Once()
 				} else {
-					urlSearcherMock.On("GetUrl", tc.alias).
-						Return(tc.mockURL, nil).
+					urlSearcherMock.On("GetLink", tc.alias).
+						Return(models.Link{ID: 1, Alias: tc.alias, URL: tc.mockURL}, nil).
+						Once()
+					clickTrackerMock.On("Track", mock.MatchedBy(func(ev clicks.Event) bool {
+						return ev.LinkID == 1 && ev.Alias == tc.alias && ev.IP == "127.0.0.1"
+					})).
 						Once()
 				}
 			}
 
-			handler := Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock)
+			handler := Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes)
 
 			r := chi.NewRouter()
 			r.Get("/{alias}", handler)
@@ -114,3 +128,887 @@ func TestRedirectHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectExpiration(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	maxClicks := int64(10)
+
+	cases := []struct {
+		name         string
+		link         models.Link
+		consumeError error
+		wantConsume  bool
+		wantCode     int
+	}{
+		{
+			name:     "Expired by date",
+			link:     models.Link{ID: 1, Alias: "promo", URL: "https://google.com", ExpiresAt: &past},
+			wantCode: http.StatusGone,
+		},
+		{
+			name:        "Clicks left",
+			link:        models.Link{ID: 1, Alias: "promo", URL: "https://google.com", ExpiresAt: &future, MaxClicks: &maxClicks},
+			wantConsume: true,
+			wantCode:    http.StatusFound,
+		},
+		{
+			name:         "Out of clicks",
+			link:         models.Link{ID: 1, Alias: "promo", URL: "https://google.com", MaxClicks: &maxClicks},
+			wantConsume:  true,
+			consumeError: storage.ErrLinkExhausted,
+			wantCode:     http.StatusGone,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
+
+			urlSearcherMock.On("GetLink", tc.link.Alias).Return(tc.link, nil).Once()
+
+			if tc.wantConsume {
+				urlSearcherMock.On("ConsumeClick", tc.link).Return(tc.consumeError).Once()
+			}
+
+			if tc.wantCode == http.StatusFound {
+				clickTrackerMock.On("Track", mock.Anything).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/"+tc.link.Alias, nil))
+
+			require.Equal(t, tc.wantCode, rr.Code)
+		})
+	}
+}
+
+func TestRedirectProtected(t *testing.T) {
+	urlSearcherMock := mocks.NewURLSearcher(t)
+	clickTrackerMock := mocks.NewClickTracker(t)
+
+	urlSearcherMock.On("GetLink", "secret").
+		Return(models.Link{ID: 1, Alias: "secret", URL: "https://google.com", Protected: true}, nil).
+		Twice()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/secret", nil))
+
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "password required")
+
+	req := httptest.NewRequest(http.MethodGet, "/secret", nil)
+	req.Header.Set("Accept", "text/html")
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `type="password"`)
+}
+
+func TestRedirectSchedule(t *testing.T) {
+	launch := time.Now().Add(24 * time.Hour)
+	launched := time.Now().Add(-time.Minute)
+
+	cases := []struct {
+		name     string
+		link     models.Link
+		html     bool
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Not live yet",
+			link:     models.Link{ID: 1, Alias: "launch", URL: "https://example.com", NotBefore: &launch},
+			wantCode: http.StatusNotFound,
+			wantBody: "URL not found",
+		},
+		{
+			name:     "Coming soon page",
+			link:     models.Link{ID: 1, Alias: "launch", URL: "https://example.com", NotBefore: &launch},
+			html:     true,
+			wantCode: http.StatusNotFound,
+			wantBody: "Coming soon",
+		},
+		{
+			name:     "Live",
+			link:     models.Link{ID: 1, Alias: "launch", URL: "https://example.com", NotBefore: &launched},
+			wantCode: http.StatusFound,
+		},
+		{
+			name:     "Paused",
+			link:     models.Link{ID: 1, Alias: "launch", URL: "https://example.com", Paused: true},
+			wantCode: http.StatusNotFound,
+			wantBody: "URL not found",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
+
+			urlSearcherMock.On("GetLink", "launch").Return(tc.link, nil).Once()
+			if tc.wantCode == http.StatusFound {
+				clickTrackerMock.On("Track", mock.Anything).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes))
+
+			req := httptest.NewRequest(http.MethodGet, "/launch", nil)
+			if tc.html {
+				req.Header.Set("Accept", "text/html")
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.wantBody)
+
+			if tc.wantCode != http.StatusFound {
+				assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestRedirectPreview(t *testing.T) {
+	link := models.Link{
+		ID:          1,
+		Alias:       "docs",
+		URL:         "https://example.com/docs?lang=en",
+		Description: "Release <notes>",
+		ForwardPath: true,
+	}
+
+	cases := []struct {
+		name     string
+		path     string
+		always   bool
+		wantCode int
+		wantBody []string
+	}{
+		{
+			name:     "Plus suffix",
+			path:     "/docs+",
+			wantCode: http.StatusOK,
+			wantBody: []string{"example.com", "https://example.com/docs?lang=en", "Release &lt;notes&gt;", `href="/docs?preview=0"`},
+		},
+		{
+			name:     "Query switch",
+			path:     "/docs?preview=1&ref=mail",
+			wantCode: http.StatusOK,
+			wantBody: []string{`href="/docs?preview=0&amp;ref=mail"`},
+		},
+		{
+			name:     "Forwarded path kept",
+			path:     "/docs+/guide%2Fv1?ref=mail",
+			wantCode: http.StatusOK,
+			wantBody: []string{`href="/docs/guide%2Fv1?preview=0&amp;ref=mail"`},
+		},
+		{
+			name:     "Always preview",
+			path:     "/docs",
+			always:   true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Continue",
+			path:     "/docs?preview=0",
+			always:   true,
+			wantCode: http.StatusFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			link := link
+			link.AlwaysPreview = tc.always
+
+			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
+
+			urlSearcherMock.On("GetLink", "docs").Return(link, nil).Once()
+			if tc.wantCode == http.StatusFound {
+				clickTrackerMock.On("Track", mock.Anything).Once()
+			}
+
+			handler := Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes)
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", handler)
+			r.Get("/{alias}/*", handler)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			if tc.wantCode == http.StatusFound {
+				// the switch is not passed on to the target
+				assert.Equal(t, link.URL, rr.Header().Get("Location"))
+				return
+			}
+
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			for _, want := range tc.wantBody {
+				assert.Contains(t, rr.Body.String(), want)
+			}
+		})
+	}
+}
+
+func TestRedirectDeepLink(t *testing.T) {
+	link := models.Link{
+		ID:    1,
+		Alias: "app",
+		URL:   "https://example.com/promo",
+		DeepLink: &models.DeepLink{
+			AppURI:      "myapp://promo?id=1",
+			IOSStoreURL: "https://apps.apple.com/app/id1",
+		},
+	}
+
+	cases := []struct {
+		name         string
+		ua           string
+		wantCode     int
+		wantFallback string
+	}{
+		{
+			name:         "iPhone",
+			ua:           "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+			wantCode:     http.StatusOK,
+			wantFallback: "https://apps.apple.com/app/id1",
+		},
+		{
+			name:         "Android without store",
+			ua:           "Mozilla/5.0 (Linux; Android 14; Pixel 8)",
+			wantCode:     http.StatusOK,
+			wantFallback: "https://example.com/promo",
+		},
+		{
+			name:     "Desktop",
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			wantCode: http.StatusFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
+
+			urlSearcherMock.On("GetLink", "app").Return(link, nil).Once()
+			// opening the app counts as a click too
+			clickTrackerMock.On("Track", mock.Anything).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes))
+
+			req := httptest.NewRequest(http.MethodGet, "/app", nil)
+			req.Header.Set("User-Agent", tc.ua)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+			assert.Contains(t, rr.Header().Get("Vary"), "User-Agent")
+
+			if tc.wantCode == http.StatusFound {
+				assert.Equal(t, link.URL, rr.Header().Get("Location"))
+				return
+			}
+
+			body := rr.Body.String()
+			assert.Contains(t, body, `href="myapp://promo?id=1"`)
+			assert.Contains(t, body, `window.location.href = "myapp://promo?id=1"`)
+			assert.Contains(t, body, `window.location.replace("`+tc.wantFallback+`")`)
+		})
+	}
+}
+
+func TestRedirectPage(t *testing.T) {
+	link := models.Link{
+		ID:    3,
+		Alias: "me",
+		URL:   "https://sho.rt/me",
+		Title: "My links",
+		Page:  &models.Page{Theme: models.PageThemeDark},
+	}
+
+	urlSearcherMock := mocks.NewURLSearcher(t)
+	clickTrackerMock := mocks.NewClickTracker(t)
+
+	urlSearcherMock.On("GetLink", "me").Return(link, nil).Once()
+	urlSearcherMock.On("PageItems", int64(3)).
+		Return([]models.PageItem{{Alias: "blog", Title: "Blog"}, {Alias: "shop", Position: 1}}, nil).
+		Once()
+	clickTrackerMock.On("Track", mock.MatchedBy(func(ev clicks.Event) bool { return ev.LinkID == 3 })).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/me", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	body := rr.Body.String()
+	assert.Contains(t, body, `<body class="dark">`)
+	assert.Contains(t, body, `<a href="/blog">Blog</a>`)
+	// items without a title show their alias
+	assert.Contains(t, body, `<a href="/shop">shop</a>`)
+}
+
+func TestRedirectRules(t *testing.T) {
+	link := models.Link{
+		ID:    1,
+		Alias: "app",
+		URL:   "https://example.com/app",
+		Rules: []models.Rule{
+			{ID: 1, Platform: models.PlatformIOS, URL: "https://apps.apple.com/app/id1"},
+			{ID: 2, Platform: models.PlatformAndroid, URL: "https://play.google.com/store/apps/details?id=app"},
+			{ID: 3, Country: "DE", URL: "https://example.de/app"},
+		},
+	}
+
+	cases := []struct {
+		name    string
+		ua      string
+		country string
+		want    string
+	}{
+		{
+			name: "iPhone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+			want: "https://apps.apple.com/app/id1",
+		},
+		{
+			name: "Android",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8)",
+			want: "https://play.google.com/store/apps/details?id=app",
+		},
+		{
+			name:    "Desktop in Germany",
+			ua:      "Mozilla/5.0 (X11; Linux x86_64)",
+			country: "DE",
+			want:    "https://example.de/app",
+		},
+		{
+			name: "Default",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64)",
+			want: "https://example.com/app",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
+			geoMock := rulesmocks.NewCountryResolver(t)
+
+			urlSearcherMock.On("GetLink", "app").Return(link, nil).Once()
+			clickTrackerMock.On("Track", mock.Anything).Once()
+			geoMock.On("Country", "192.0.2.1").Return(tc.country).Maybe()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, geoMock, noDomains(t), testCodes))
+
+			req := httptest.NewRequest(http.MethodGet, "/app", nil)
+			req.Header.Set("User-Agent", tc.ua)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, tc.want, rr.Header().Get("Location"))
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			require.NotEmpty(t, rr.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestRedirectVariants(t *testing.T) {
+	link := models.Link{
+		ID:    9,
+		Alias: "ab",
+		URL:   "https://example.com",
+		Variants: []models.Variant{
+			{ID: 1, Name: "a", URL: "https://example.com/a", Weight: 1},
+			{ID: 2, Name: "b", URL: "https://example.com/b", Weight: 1},
+		},
+	}
+
+	urlSearcherMock := mocks.NewURLSearcher(t)
+	clickTrackerMock := mocks.NewClickTracker(t)
+
+	urlSearcherMock.On("GetLink", "ab").Return(link, nil).Twice()
+
+	var first clicks.Event
+	clickTrackerMock.On("Track", mock.AnythingOfType("clicks.Event")).
+		Run(func(args mock.Arguments) { first = args.Get(0).(clicks.Event) }).
+		Once()
+	clickTrackerMock.On("Track", mock.MatchedBy(func(ev clicks.Event) bool { return ev.Variant == "b" })).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes))
+
+	// a new visitor gets a variant and the cookie to keep it
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ab", nil))
+
+	require.Equal(t, http.StatusFound, rr.Code)
+	require.Contains(t, []string{"https://example.com/a", "https://example.com/b"}, rr.Header().Get("Location"))
+	require.Equal(t, "https://example.com/"+first.Variant, rr.Header().Get("Location"))
+
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, "ab_9", cookies[0].Name)
+	require.Equal(t, first.Variant, cookies[0].Value)
+
+	// a returning visitor keeps the variant from the cookie
+	req := httptest.NewRequest(http.MethodGet, "/ab", nil)
+	req.AddCookie(&http.Cookie{Name: "ab_9", Value: "b"})
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, "https://example.com/b", rr.Header().Get("Location"))
+	require.Empty(t, rr.Result().Cookies())
+}
+
+func TestRedirectCodes(t *testing.T) {
+	soon := time.Now().Add(10 * time.Minute)
+	maxClicks := int64(5)
+
+	cases := []struct {
+		name      string
+		link      models.Link
+		method    string
+		wantCode  int
+		wantCache string
+		tracked   bool
+	}{
+		{
+			name:      "Service default",
+			link:      models.Link{ID: 1, Alias: "a", URL: "https://example.com"},
+			method:    http.MethodGet,
+			wantCode:  http.StatusFound,
+			wantCache: "no-store",
+			tracked:   true,
+		},
+		{
+			name:      "Permanent",
+			link:      models.Link{ID: 1, Alias: "a", URL: "https://example.com", RedirectCode: http.StatusMovedPermanently},
+			method:    http.MethodGet,
+			wantCode:  http.StatusMovedPermanently,
+			wantCache: "public, max-age=3600",
+			tracked:   true,
+		},
+		{
+			name:      "Permanent until expiration",
+			link:      models.Link{ID: 1, Alias: "a", URL: "https://example.com", RedirectCode: http.StatusPermanentRedirect, ExpiresAt: &soon},
+			method:    http.MethodGet,
+			wantCode:  http.StatusPermanentRedirect,
+			wantCache: "public, max-age=599",
+			tracked:   true,
+		},
+		{
+			name:      "Temporary 307",
+			link:      models.Link{ID: 1, Alias: "a", URL: "https://example.com", RedirectCode: http.StatusTemporaryRedirect},
+			method:    http.MethodGet,
+			wantCode:  http.StatusTemporaryRedirect,
+			wantCache: "no-store",
+			tracked:   true,
+		},
+		{
+			name:      "HEAD is not counted",
+			link:      models.Link{ID: 1, Alias: "a", URL: "https://example.com", RedirectCode: http.StatusMovedPermanently},
+			method:    http.MethodHead,
+			wantCode:  http.StatusMovedPermanently,
+			wantCache: "public, max-age=3600",
+		},
+		{
+			name:      "HEAD of a limited link",
+			link:      models.Link{ID: 1, Alias: "a", URL: "https://example.com", MaxClicks: &maxClicks, Clicks: 1},
+			method:    http.MethodHead,
+			wantCode:  http.StatusFound,
+			wantCache: "no-store",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
+
+			urlSearcherMock.On("GetLink", "a").Return(tc.link, nil).Once()
+			if tc.tracked {
+				clickTrackerMock.On("Track", mock.Anything).Once()
+			}
+
+			handler := Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes)
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", handler)
+			r.Head("/{alias}", handler)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(tc.method, "/a", nil))
+
+			require.Equal(t, tc.wantCode, rr.Code)
+			require.Equal(t, "https://example.com", rr.Header().Get("Location"))
+
+			// the expiration may move by a second while the test runs
+			if tc.link.ExpiresAt != nil && tc.link.RedirectCode != 0 {
+				require.Contains(t, []string{"public, max-age=599", "public, max-age=600"}, rr.Header().Get("Cache-Control"))
+				return
+			}
+			require.Equal(t, tc.wantCache, rr.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestRedirectPassthrough(t *testing.T) {
+	cases := []struct {
+		name     string
+		link     models.Link
+		path     string
+		wantCode int
+		want     string
+	}{
+		{
+			name:     "Query merged",
+			link:     models.Link{ID: 1, Alias: "docs", URL: "https://docs.example.com/v2?lang=en", ForwardQuery: true},
+			path:     "/docs?utm_source=x",
+			wantCode: http.StatusFound,
+			want:     "https://docs.example.com/v2?lang=en&utm_source=x",
+		},
+		{
+			name:     "Query dropped",
+			link:     models.Link{ID: 1, Alias: "docs", URL: "https://docs.example.com/v2"},
+			path:     "/docs?utm_source=x",
+			wantCode: http.StatusFound,
+			want:     "https://docs.example.com/v2",
+		},
+		{
+			name:     "Path forwarded",
+			link:     models.Link{ID: 1, Alias: "docs", URL: "https://docs.example.com/v2", ForwardPath: true},
+			path:     "/docs/guide/install",
+			wantCode: http.StatusFound,
+			want:     "https://docs.example.com/v2/guide/install",
+		},
+		{
+			name:     "Encoded slash forwarded once",
+			link:     models.Link{ID: 1, Alias: "docs", URL: "https://docs.example.com/v2", ForwardPath: true},
+			path:     "/docs/files/a%2Fb",
+			wantCode: http.StatusFound,
+			want:     "https://docs.example.com/v2/files/a%2Fb",
+		},
+		{
+			name:     "Path without passthrough",
+			link:     models.Link{ID: 1, Alias: "docs", URL: "https://docs.example.com/v2"},
+			path:     "/docs/guide/install",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
+
+			urlSearcherMock.On("GetLink", "docs").Return(tc.link, nil).Once()
+			if tc.wantCode == http.StatusFound {
+				clickTrackerMock.On("Track", mock.Anything).Once()
+			}
+
+			handler := Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes)
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", handler)
+			r.Get("/{alias}/*", handler)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, tc.wantCode, rr.Code)
+			require.Equal(t, tc.want, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestUnlockHandler(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("qwerty"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		password  string
+		blocked   bool
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Success",
+			password: "qwerty",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:      "Wrong password",
+			password:  "12345",
+			respError: "invalid password",
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			name:      "Too many attempts",
+			password:  "qwerty",
+			blocked:   true,
+			respError: "too many attempts",
+			wantCode:  http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
+			limiterMock := mocks.NewAttemptLimiter(t)
+
+			const key = "secret:192.0.2.1"
+
+			urlSearcherMock.On("GetLink", "secret").
+				Return(models.Link{ID: 1, Alias: "secret", URL: "https://google.com", Protected: true}, nil).
+				Once()
+			limiterMock.On("Attempt", mock.Anything, key).Return(tc.blocked, nil).Once()
+
+			if !tc.blocked {
+				urlSearcherMock.On("LinkPasswordHash", int64(1)).Return(hash, nil).Once()
+			}
+
+			switch tc.wantCode {
+			case http.StatusSeeOther:
+				limiterMock.On("Reset", mock.Anything, key).Return(nil).Once()
+				clickTrackerMock.On("Track", mock.Anything).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/{alias}", Unlock(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, limiterMock, rulesmocks.NewCountryResolver(t), noDomains(t)))
+
+			req := httptest.NewRequest(http.MethodPost, "/secret", strings.NewReader(`{"password":"`+tc.password+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			if tc.wantCode == http.StatusSeeOther {
+				assert.Equal(t, "https://google.com", rr.Header().Get("Location"))
+			}
+			if tc.respError != "" {
+				assert.Contains(t, rr.Body.String(), tc.respError)
+			}
+		})
+	}
+}
+
+func TestUnlockPassthrough(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("qwerty"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	link := models.Link{ID: 1, Alias: "secret", URL: "https://docs.example.com/v2", Protected: true, ForwardPath: true}
+
+	urlSearcherMock := mocks.NewURLSearcher(t)
+	clickTrackerMock := mocks.NewClickTracker(t)
+	limiterMock := mocks.NewAttemptLimiter(t)
+
+	urlSearcherMock.On("GetLink", "secret").Return(link, nil).Twice()
+	urlSearcherMock.On("LinkPasswordHash", int64(1)).Return(hash, nil).Once()
+	limiterMock.On("Attempt", mock.Anything, mock.Anything).Return(false, nil).Once()
+	limiterMock.On("Reset", mock.Anything, mock.Anything).Return(nil).Once()
+	clickTrackerMock.On("Track", mock.Anything).Once()
+
+	redirect := Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), noDomains(t), testCodes)
+	unlock := Unlock(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, limiterMock, rulesmocks.NewCountryResolver(t), noDomains(t))
+
+	r := chi.NewRouter()
+	r.Get("/{alias}/*", redirect)
+	r.Post("/{alias}/*", unlock)
+
+	// the form is posted back to the forwarded path
+	req := httptest.NewRequest(http.MethodGet, "/secret/files/a%2Fb?lang=en", nil)
+	req.Header.Set("Accept", "text/html")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `action="/secret/files/a%2Fb?lang=en"`)
+
+	req = httptest.NewRequest(http.MethodPost, "/secret/files/a%2Fb?lang=en", strings.NewReader("password=qwerty"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "https://docs.example.com/v2/files/a%2Fb", rr.Header().Get("Location"))
+}
+
+func TestRedirectDomain(t *testing.T) {
+	acme := models.Domain{ID: 1, UserID: 5, Host: "go.acme.com", NotFoundURL: "https://acme.com/missing"}
+	plain := models.Domain{ID: 2, UserID: 5, Host: "acme.link"}
+
+	cases := []struct {
+		name      string
+		host      string
+		domain    models.Domain
+		ref       string
+		lookup    bool
+		mockError error
+		wantCode  int
+		wantURL   string
+	}{
+		{
+			name:     "Custom domain",
+			host:     "Go.Acme.com:443",
+			domain:   acme,
+			ref:      "go.acme.com/promo",
+			lookup:   true,
+			wantCode: http.StatusFound,
+			wantURL:  "https://acme.com/spring",
+		},
+		{
+			name:     "Service domain",
+			host:     "sho.rt",
+			ref:      "promo",
+			wantCode: http.StatusFound,
+			wantURL:  "https://acme.com/spring",
+		},
+		{
+			name:     "Unknown host",
+			host:     "other.example",
+			ref:      "promo",
+			lookup:   true,
+			wantCode: http.StatusFound,
+			wantURL:  "https://acme.com/spring",
+		},
+		{
+			name:     "Host that is no domain",
+			host:     "127.0.0.1:8080",
+			ref:      "promo",
+			wantCode: http.StatusFound,
+			wantURL:  "https://acme.com/spring",
+		},
+		{
+			name:     "Malformed host",
+			host:     "evil_host!.example",
+			ref:      "promo",
+			wantCode: http.StatusFound,
+			wantURL:  "https://acme.com/spring",
+		},
+		{
+			name:      "Missing with fallback",
+			host:      "go.acme.com",
+			domain:    acme,
+			ref:       "go.acme.com/promo",
+			lookup:    true,
+			mockError: storage.ErrURLNotFound,
+			wantCode:  http.StatusFound,
+			wantURL:   "https://acme.com/missing",
+		},
+		{
+			name:      "Missing without fallback",
+			host:      "acme.link",
+			domain:    plain,
+			ref:       "acme.link/promo",
+			lookup:    true,
+			mockError: storage.ErrURLNotFound,
+			wantCode:  http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSearcherMock := mocks.NewURLSearcher(t)
+			clickTrackerMock := mocks.NewClickTracker(t)
+			domainsMock := mocks.NewDomainResolver(t)
+
+			if tc.lookup {
+				var domainErr error
+				if tc.domain.ID == 0 {
+					domainErr = storage.ErrDomainNotFound
+				}
+				domainsMock.On("Domain", strings.ToLower(strings.Split(tc.host, ":")[0])).Return(tc.domain, domainErr).Once()
+			}
+
+			urlSearcherMock.On("GetLink", tc.ref).
+				Return(models.Link{ID: 9, Alias: "promo", Domain: tc.domain.Host, URL: "https://acme.com/spring"}, tc.mockError).
+				Once()
+			if tc.mockError == nil {
+				clickTrackerMock.On("Track", mock.MatchedBy(func(ev clicks.Event) bool { return ev.Alias == tc.ref })).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(slogdiscard.NewDiscardLogger(), urlSearcherMock, clickTrackerMock, rulesmocks.NewCountryResolver(t), Domains{Resolver: domainsMock, ServiceHosts: []string{"sho.rt"}}, testCodes))
+
+			req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+			req.Host = tc.host
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+			require.Equal(t, tc.wantURL, rr.Header().Get("Location"))
+		})
+	}
+}
+
+// noDomains serves every request from the domain of the service
+func noDomains(t *testing.T) Domains {
+	t.Helper()
+
+	domainsMock := mocks.NewDomainResolver(t)
+	domainsMock.On("Domain", mock.Anything).Return(models.Domain{}, storage.ErrDomainNotFound).Maybe()
+
+	return Domains{Resolver: domainsMock}
+}
@@ -0,0 +1,68 @@
+package tags
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/collection"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Tag *models.Tag `json:"tag,omitempty"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Tags []models.Tag `json:"tags"`
+}
+
+//go:generate mockery --name=TagStorage --dir=. --output=./mocks --filename=tag_storage_mock.go --outpkg=mocks
+type TagStorage interface {
+	Tags(userID int64) ([]models.Tag, error)
+	CreateTag(userID int64, name string) (models.Tag, error)
+	RenameTag(userID, tagID int64, name string) error
+	DeleteTag(userID, tagID int64) error
+}
+
+// List returns tags of the user
+func List(log *slog.Logger, tagStorage TagStorage) http.HandlerFunc {
+	return collection.List(log, kind(tagStorage))
+}
+
+// Create adds a tag, tags are also created when assigned to a link
+func Create(log *slog.Logger, tagStorage TagStorage) http.HandlerFunc {
+	return collection.Create(log, kind(tagStorage))
+}
+
+// Rename changes the name of the tag on all of its links
+func Rename(log *slog.Logger, tagStorage TagStorage) http.HandlerFunc {
+	return collection.Rename(log, kind(tagStorage))
+}
+
+// Delete removes the tag from the user and all of the links
+func Delete(log *slog.Logger, tagStorage TagStorage) http.HandlerFunc {
+	return collection.Delete(log, kind(tagStorage))
+}
+
+func kind(tagStorage TagStorage) collection.Kind[models.Tag] {
+	return collection.Kind[models.Tag]{
+		Op:          "handlers.tags",
+		Name:        "tag",
+		ErrExists:   storage.ErrTagExists,
+		ErrNotFound: storage.ErrTagNotFound,
+		List:        tagStorage.Tags,
+		Create:      tagStorage.CreateTag,
+		Rename:      tagStorage.RenameTag,
+		Delete:      tagStorage.DeleteTag,
+		Item: func(tag *models.Tag) any {
+			return Response{Response: resp.OK(), Tag: tag}
+		},
+		Items: func(tags []models.Tag) any {
+			return ListResponse{Response: resp.OK(), Tags: tags}
+		},
+	}
+}
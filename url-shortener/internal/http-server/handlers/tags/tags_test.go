@@ -0,0 +1,169 @@
+package tags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/tags/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 4
+
+func TestCreateHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		mockName  string
+		mockError error
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Success",
+			body:     `{"name":" promo "}`,
+			mockName: "promo",
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:      "Empty name",
+			body:      `{"name":"  "}`,
+			respError: "field Name is a required field",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Duplicate",
+			body:      `{"name":"promo"}`,
+			mockName:  "promo",
+			mockError: storage.ErrTagExists,
+			respError: "tag already exists",
+			wantCode:  http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewTagStorage(t)
+
+			if tc.mockName != "" {
+				storageMock.On("CreateTag", int64(userID), tc.mockName).
+					Return(models.Tag{ID: 1, Name: tc.mockName}, tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/tags", Create(slogdiscard.NewDiscardLogger(), storageMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/tags", strings.NewReader(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+func TestRenameHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		path      string
+		mockCall  bool
+		mockError error
+		wantCode  int
+	}{
+		{
+			name:     "Success",
+			path:     "/tags/7",
+			mockCall: true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Invalid id",
+			path:     "/tags/abc",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:      "Someone else's tag",
+			path:      "/tags/7",
+			mockCall:  true,
+			mockError: storage.ErrTagNotFound,
+			wantCode:  http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewTagStorage(t)
+
+			if tc.mockCall {
+				storageMock.On("RenameTag", int64(userID), int64(7), "sale").Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Patch("/tags/{id}", Rename(slogdiscard.NewDiscardLogger(), storageMock))
+
+			req := httptest.NewRequest(http.MethodPatch, tc.path, strings.NewReader(`{"name":"sale"}`))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+		})
+	}
+}
+
+func TestDeleteHandler(t *testing.T) {
+	storageMock := mocks.NewTagStorage(t)
+	storageMock.On("DeleteTag", int64(userID), int64(7)).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Delete("/tags/{id}", Delete(slogdiscard.NewDiscardLogger(), storageMock))
+
+	req := httptest.NewRequest(http.MethodDelete, "/tags/7", nil)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestListHandler(t *testing.T) {
+	storageMock := mocks.NewTagStorage(t)
+	storageMock.On("Tags", int64(userID)).Return([]models.Tag{{ID: 1, Name: "promo"}}, nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/tags", List(slogdiscard.NewDiscardLogger(), storageMock))
+
+	req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var got ListResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Len(t, got.Tags, 1)
+}
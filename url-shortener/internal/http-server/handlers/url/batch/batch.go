@@ -0,0 +1,607 @@
+package batch
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/save"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/alias"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/deeplink"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Result is the outcome of a single item of the batch
+type Result struct {
+	Index  int    `json:"index"`
+	URL    string `json:"url"`
+	Alias  string `json:"alias,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Created int      `json:"created"`
+	Failed  int      `json:"failed"`
+	Results []Result `json:"results"`
+}
+
+//go:generate mockery --name=URLsSaver --dir=. --output=./mocks --filename=urls_saver_mock.go --outpkg=mocks
+type URLsSaver interface {
+	SaveURLs(links []models.Link, atomic bool) ([]int64, []error, error)
+}
+
+//go:generate mockery --name=BatchProducer --dir=. --output=./mocks --filename=batch_producer_mock.go --outpkg=mocks
+type BatchProducer interface {
+	PublishBatch(ctx context.Context, messages ...kafka.Message) error
+}
+
+const (
+	maxItems     = 5000
+	maxBodyBytes = 10 << 20
+	// maxPasswords limits items with a password, every one costs a bcrypt hash
+	maxPasswords = 100
+	// hashTimeout bounds the time spent hashing passwords of a batch
+	hashTimeout = 3 * time.Second
+)
+
+var (
+	errTooManyItems     = fmt.Errorf("batch must contain from 1 to %d items", maxItems)
+	errTooManyPasswords = fmt.Errorf("batch may contain at most %d items with a password", maxPasswords)
+)
+
+// templateResult keeps the template looked up for the rows of a batch, 0 is the default one
+type templateResult struct {
+	models.UTMTemplate
+	err error
+}
+
+// New creates links from a JSON array or a CSV file.
+// With ?atomic=true nothing is saved when any item fails,
+// otherwise valid items are saved and failures are reported per item
+func New(
+	log *slog.Logger,
+	saver URLsSaver,
+	producer BatchProducer,
+	aliases *alias.Validator,
+	generator save.AliasGenerator,
+	checker urlpolicy.Checker,
+	templates save.UTMTemplates,
+	domains save.DomainLookup,
+) http.HandlerFunc {
+	validate := validator.New()
+	aliases.Register(validate)
+	save.Register(validate)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.batch.New"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		var atomic bool
+		if v := r.URL.Query().Get("atomic"); v != "" {
+			var err error
+			if atomic, err = strconv.ParseBool(v); err != nil {
+				resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("atomic must be true or false"))
+				return
+			}
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+		reqs, results, err := decode(r)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
+		}
+
+		log.Info("batch decoded", slog.Int("items", len(reqs)))
+
+		links := make([]models.Link, 0, len(reqs))
+		indexes := make([]int, 0, len(reqs))
+		generated := make([]bool, 0, len(reqs))
+		passwords := make([]string, 0, len(reqs))
+		protected := 0
+
+		var audit []kafka.Message
+
+		// rows of a batch usually share the domain and the template, they are looked up once
+		checked := make(map[string]error)
+		tmpls := make(map[int64]templateResult)
+
+		for i, req := range reqs {
+			if results[i].Error != "" {
+				continue
+			}
+
+			if err := validate.Struct(req); err != nil {
+				var validateErr validator.ValidationErrors
+				errors.As(err, &validateErr)
+				results[i].Error = resp.ValidationError(validateErr).Error
+				continue
+			}
+
+			req.Domain = models.NormalizeHost(req.Domain)
+			domainErr, ok := checked[req.Domain]
+			if !ok {
+				domainErr = save.CheckDomain(domains, int64(userID), req.Domain)
+				if domainErr != nil && !errors.Is(domainErr, save.ErrDomainNotVerified) {
+					log.Error("failed to get domain", sl.Err(domainErr))
+					resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URLs"))
+					return
+				}
+				checked[req.Domain] = domainErr
+			}
+			if domainErr != nil {
+				results[i].Error = domainErr.Error()
+				continue
+			}
+
+			var tmplID int64
+			if req.UTMTemplateID != nil {
+				tmplID = *req.UTMTemplateID
+			}
+			tmpl, ok := tmpls[tmplID]
+			if !ok {
+				tmpl.UTMTemplate, tmpl.err = save.TemplateFor(templates, int64(userID), req.UTMTemplateID)
+				if tmpl.err != nil && !errors.Is(tmpl.err, storage.ErrTemplateNotFound) {
+					log.Error("failed to get utm template", sl.Err(tmpl.err))
+					resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URLs"))
+					return
+				}
+				tmpls[tmplID] = tmpl
+			}
+			if tmpl.err != nil {
+				results[i].Error = "template not found"
+				continue
+			}
+
+			var utm models.UTM
+			if req.UTM != nil {
+				utm = *req.UTM
+			}
+
+			if req.URL, err = utm.WithDefaults(tmpl.UTM).Apply(req.URL); err != nil {
+				results[i].Error = "invalid url"
+				continue
+			}
+
+			if req.DeepLink != nil {
+				if err := deeplink.Check(r.Context(), checker, *req.DeepLink); err != nil {
+					results[i].Error = err.Error()
+					continue
+				}
+			}
+
+			if err := checker.Check(r.Context(), req.URL); err != nil {
+				log.Warn("target URL rejected", slog.String("url", req.URL), sl.Err(err))
+				results[i].Error = err.Error()
+				audit = append(audit, kafka.Message{
+					Key:   strconv.FormatInt(int64(userID), 10),
+					Value: urlpolicy.Event(r, int64(userID), req.URL, err, "batch"),
+				})
+				continue
+			}
+
+			link := models.Link{
+				Alias:         req.Alias,
+				Domain:        req.Domain,
+				URL:           req.URL,
+				UserID:        int64(userID),
+				ExpiresAt:     req.ExpiresAt,
+				MaxClicks:     req.MaxClicks,
+				RedirectCode:  req.RedirectCode,
+				ForwardQuery:  req.ForwardQuery,
+				ForwardPath:   req.ForwardPath,
+				NotBefore:     req.NotBefore,
+				Title:         strings.TrimSpace(req.Title),
+				Description:   strings.TrimSpace(req.Description),
+				AlwaysPreview: req.AlwaysPreview,
+				DeepLink:      req.DeepLink,
+				FolderID:      req.FolderID,
+				Tags:          models.NormalizeTags(req.Tags),
+			}
+			if link.Alias == "" {
+				if err := aliases.Generate(generator, &link); err != nil {
+					log.Error("failed to generate alias", sl.Err(err))
+					resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URLs"))
+					return
+				}
+			}
+
+			if req.Password != "" {
+				if protected == maxPasswords {
+					results[i].Error = errTooManyPasswords.Error()
+					continue
+				}
+				protected++
+			}
+
+			links = append(links, link)
+			indexes = append(indexes, i)
+			generated = append(generated, req.Alias == "")
+			passwords = append(passwords, req.Password)
+		}
+
+		if len(audit) > 0 {
+			if err := producer.PublishBatch(context.Background(), audit...); err != nil {
+				log.Error("failed to send messages to Kafka", sl.Err(err))
+			}
+		}
+
+		if atomic && len(links) < len(reqs) {
+			log.Info("batch rejected by validation")
+			finish(w, r, http.StatusBadRequest, results, storage.ErrBatchRejected)
+			return
+		}
+
+		if err := hashPasswords(r.Context(), links, passwords); err != nil {
+			log.Error("failed to hash passwords", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URLs"))
+			return
+		}
+
+		var (
+			ids  []int64
+			errs []error
+		)
+		if len(links) > 0 {
+			ids, errs, err = saveLinks(saver, aliases, generator, links, generated, atomic)
+			if err != nil && !errors.Is(err, storage.ErrBatchRejected) {
+				log.Error("failed to save batch", sl.Err(err))
+				resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URLs"))
+				return
+			}
+		}
+
+		for j, i := range indexes {
+			results[i].Alias = links[j].Alias
+
+			switch {
+			case errors.Is(errs[j], storage.ErrURLExists):
+				results[i].Error = "URL already exists"
+			case errors.Is(errs[j], storage.ErrAliasExists):
+				results[i].Error = "alias already exists"
+			case errors.Is(errs[j], storage.ErrFolderNotFound):
+				results[i].Error = "folder not found"
+			case errs[j] != nil:
+				results[i].Error = "failed to add URL"
+			}
+		}
+
+		if errors.Is(err, storage.ErrBatchRejected) {
+			log.Info("batch rejected by conflict")
+			finish(w, r, http.StatusConflict, results, err)
+			return
+		}
+
+		now := time.Now().UTC()
+		messages := make([]kafka.Message, 0, len(ids))
+
+		for j, link := range links {
+			if errs[j] != nil {
+				continue
+			}
+
+			ev := map[string]interface{}{
+				"type":      kafka.EventLinkSaved,
+				"timestamp": now,
+				"user_id":   userID,
+				"alias":     link.Ref(),
+				"url":       link.URL,
+				"link_id":   ids[j],
+				"tags":      link.Tags,
+			}
+			if link.Domain != "" {
+				ev["domain"] = link.Domain
+			}
+			if link.NotBefore != nil {
+				ev["not_before"] = link.NotBefore
+			}
+			for key, value := range models.ParseUTM(link.URL).Fields() {
+				ev[key] = value
+			}
+
+			messages = append(messages, kafka.Message{
+				Key:   strconv.FormatInt(int64(userID), 10),
+				Value: ev,
+			})
+		}
+
+		if len(messages) > 0 {
+			if err := producer.PublishBatch(context.Background(), messages...); err != nil {
+				log.Error("failed to send messages to Kafka", sl.Err(err))
+			}
+		}
+
+		log.Info("batch saved", slog.Int("created", len(messages)))
+
+		finish(w, r, http.StatusOK, results, nil)
+	}
+}
+
+// saveLinks stores the links and regenerates generated aliases that are already taken,
+// up to alias.MaxAttempts times. A rejected atomic batch is retried as a whole
+func saveLinks(
+	saver URLsSaver,
+	aliases *alias.Validator,
+	generator save.AliasGenerator,
+	links []models.Link,
+	generated []bool,
+	atomic bool,
+) ([]int64, []error, error) {
+	ids := make([]int64, len(links))
+	errs := make([]error, len(links))
+
+	pending := make([]int, len(links))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	for attempt := 1; ; attempt++ {
+		batch := make([]models.Link, len(pending))
+		for k, i := range pending {
+			batch[k] = links[i]
+		}
+
+		batchIDs, batchErrs, err := saver.SaveURLs(batch, atomic)
+		if err != nil && !errors.Is(err, storage.ErrBatchRejected) {
+			return nil, nil, err
+		}
+
+		var retry []int
+		for k, i := range pending {
+			ids[i], errs[i] = batchIDs[k], batchErrs[k]
+			if generated[i] && errors.Is(batchErrs[k], storage.ErrAliasExists) && attempt < alias.MaxAttempts {
+				retry = append(retry, i)
+			}
+		}
+
+		if len(retry) == 0 {
+			return ids, errs, err
+		}
+
+		for _, i := range retry {
+			if err := aliases.Generate(generator, &links[i]); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if !atomic {
+			pending = retry
+		}
+	}
+}
+
+// hashPasswords sets hashes of the passwords of the links. Hashing runs on at most
+// GOMAXPROCS goroutines and stops after hashTimeout, so a batch cannot hold the CPU for long
+func hashPasswords(ctx context.Context, links []models.Link, passwords []string) error {
+	ctx, cancel := context.WithTimeout(ctx, hashTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	errs := make([]error, len(links))
+
+	for i, password := range passwords {
+		if password == "" {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, password string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			links[i].PasswordHash, errs[i] = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		}(i, password)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// finish fills statuses of the results and writes the response
+func finish(w http.ResponseWriter, r *http.Request, code int, results []Result, err error) {
+	out := Response{Response: resp.OK(), Results: results}
+	if err != nil {
+		out.Response = resp.Error(err.Error())
+	}
+
+	for i := range results {
+		switch {
+		case results[i].Error != "":
+			results[i].Status = resp.StatusError
+			out.Failed++
+		case err != nil:
+			// valid items of a rejected batch were not saved either
+			results[i].Status = resp.StatusError
+			results[i].Error = "not saved"
+			out.Failed++
+		default:
+			results[i].Status = resp.StatusOk
+			out.Created++
+		}
+	}
+
+	resp.NewJSON(w, r, code, out)
+}
+
+// decode reads the items from a JSON array, a text/csv body or a CSV file
+// uploaded as the "file" field of a multipart form
+func decode(r *http.Request) ([]save.Request, []Result, error) {
+	var (
+		reqs    []save.Request
+		results []Result
+		err     error
+	)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "text/csv":
+		reqs, results, err = decodeCSV(r.Body)
+	case "multipart/form-data":
+		file, _, ferr := r.FormFile("file")
+		if ferr != nil {
+			return nil, nil, errors.New("file is required")
+		}
+		defer file.Close()
+
+		reqs, results, err = decodeCSV(file)
+	default:
+		if err := render.DecodeJSON(r.Body, &reqs); err != nil {
+			return nil, nil, errors.New("invalid request body")
+		}
+		results = make([]Result, len(reqs))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(reqs) == 0 || len(reqs) > maxItems {
+		return nil, nil, errTooManyItems
+	}
+
+	for i := range reqs {
+		results[i].Index = i
+		results[i].URL = reqs[i].URL
+		results[i].Alias = reqs[i].Alias
+	}
+
+	return reqs, results, nil
+}
+
+// decodeCSV reads url,alias rows. When the first row is a header
+// the columns are taken by name: url, alias, domain, expires_at, not_before, max_clicks, password,
+// utm_template_id
+func decodeCSV(body io.Reader) ([]save.Request, []Result, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid csv: %w", err)
+	}
+
+	columns := map[string]int{"url": 0, "alias": 1}
+	if len(rows) > 0 && hasHeader(rows[0]) {
+		columns = make(map[string]int, len(rows[0]))
+		for i, name := range rows[0] {
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		rows = rows[1:]
+	}
+
+	if len(rows) > maxItems {
+		return nil, nil, errTooManyItems
+	}
+
+	reqs := make([]save.Request, len(rows))
+	results := make([]Result, len(rows))
+
+	for i, row := range rows {
+		field := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[idx])
+		}
+
+		reqs[i].URL = field("url")
+		reqs[i].Alias = field("alias")
+		reqs[i].Domain = field("domain")
+		reqs[i].Password = field("password")
+
+		if v := field("expires_at"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				results[i].Error = "field ExpiresAt must be RFC 3339 time"
+				continue
+			}
+			reqs[i].ExpiresAt = &t
+		}
+
+		if v := field("not_before"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				results[i].Error = "field NotBefore must be RFC 3339 time"
+				continue
+			}
+			reqs[i].NotBefore = &t
+		}
+
+		if v := field("utm_template_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				results[i].Error = "field UTMTemplateID must be a number"
+				continue
+			}
+			reqs[i].UTMTemplateID = &id
+		}
+
+		if v := field("max_clicks"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				results[i].Error = "field MaxClicks must be a number"
+				continue
+			}
+			reqs[i].MaxClicks = &n
+		}
+	}
+
+	return reqs, results, nil
+}
+
+func hasHeader(row []string) bool {
+	for _, name := range row {
+		if strings.EqualFold(strings.TrimSpace(name), "url") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,358 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/batch/mocks"
+	savemocks "github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/alias"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 7
+
+var aliases, _ = alias.NewValidator(alias.Options{MinLength: 1, MaxLength: 32, Reserved: []string{"url"}})
+
+var policy, _ = urlpolicy.New(urlpolicy.Options{DeniedCIDRs: []string{"127.0.0.0/8"}})
+
+func TestBatchHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		query       string
+		saveCount   int
+		saveErrs    []error
+		saveErr     error
+		publish     int
+		audit       int
+		wantCode    int
+		wantResults []Result
+	}{
+		{
+			name:        "JSON with conflicts",
+			contentType: "application/json",
+			body:        `[{"url":"https://a.com","alias":"a"},{"url":"https://b.com","alias":"b"},{"url":"bad","alias":"c"},{"url":"https://d.com","alias":"url"}]`,
+			saveCount:   2,
+			saveErrs:    []error{nil, storage.ErrAliasExists},
+			publish:     1,
+			wantCode:    http.StatusOK,
+			wantResults: []Result{
+				{Index: 0, URL: "https://a.com", Alias: "a", Status: resp.StatusOk},
+				{Index: 1, URL: "https://b.com", Alias: "b", Status: resp.StatusError, Error: "alias already exists"},
+				{Index: 2, URL: "bad", Alias: "c", Status: resp.StatusError, Error: "field URL is not a valid URL"},
+				{Index: 3, URL: "https://d.com", Alias: "url", Status: resp.StatusError, Error: "field Alias is reserved"},
+			},
+		},
+		{
+			name:        "CSV with header",
+			contentType: "text/csv",
+			body:        "alias,url\npromo,https://a.com\nsale,https://b.com\n",
+			saveCount:   2,
+			saveErrs:    []error{nil, nil},
+			publish:     2,
+			wantCode:    http.StatusOK,
+			wantResults: []Result{
+				{Index: 0, URL: "https://a.com", Alias: "promo", Status: resp.StatusOk},
+				{Index: 1, URL: "https://b.com", Alias: "sale", Status: resp.StatusOk},
+			},
+		},
+		{
+			name:        "CSV with domains",
+			contentType: "text/csv",
+			body:        "url,alias,domain\nhttps://a.com,promo,Go.Acme.com\nhttps://b.com,sale,other.com\nhttps://c.com,sale,go.acme.com\n",
+			saveCount:   2,
+			saveErrs:    []error{nil, nil},
+			publish:     2,
+			wantCode:    http.StatusOK,
+			wantResults: []Result{
+				{Index: 0, URL: "https://a.com", Alias: "promo", Status: resp.StatusOk},
+				{Index: 1, URL: "https://b.com", Alias: "sale", Status: resp.StatusError, Error: "domain is not verified"},
+				{Index: 2, URL: "https://c.com", Alias: "sale", Status: resp.StatusOk},
+			},
+		},
+		{
+			name:        "Atomic rejected by validation",
+			contentType: "text/csv",
+			query:       "?atomic=true",
+			body:        "https://a.com,a\nbad,b\n",
+			wantCode:    http.StatusBadRequest,
+			wantResults: []Result{
+				{Index: 0, URL: "https://a.com", Alias: "a", Status: resp.StatusError, Error: "not saved"},
+				{Index: 1, URL: "bad", Alias: "b", Status: resp.StatusError, Error: "field URL is not a valid URL"},
+			},
+		},
+		{
+			name:        "Atomic rejected by conflict",
+			contentType: "application/json",
+			query:       "?atomic=true",
+			body:        `[{"url":"https://a.com","alias":"a"},{"url":"https://b.com","alias":"b"}]`,
+			saveCount:   2,
+			saveErrs:    []error{nil, storage.ErrURLExists},
+			saveErr:     storage.ErrBatchRejected,
+			wantCode:    http.StatusConflict,
+			wantResults: []Result{
+				{Index: 0, URL: "https://a.com", Alias: "a", Status: resp.StatusError, Error: "not saved"},
+				{Index: 1, URL: "https://b.com", Alias: "b", Status: resp.StatusError, Error: "URL already exists"},
+			},
+		},
+		{
+			name:        "Private target",
+			contentType: "application/json",
+			body:        `[{"url":"https://a.com","alias":"a"},{"url":"http://127.0.0.1:6379","alias":"b"}]`,
+			saveCount:   1,
+			saveErrs:    []error{nil},
+			publish:     1,
+			audit:       1,
+			wantCode:    http.StatusOK,
+			wantResults: []Result{
+				{Index: 0, URL: "https://a.com", Alias: "a", Status: resp.StatusOk},
+				{Index: 1, URL: "http://127.0.0.1:6379", Alias: "b", Status: resp.StatusError, Error: "URL points to a private address"},
+			},
+		},
+		{
+			name:        "Empty batch",
+			contentType: "application/json",
+			body:        `[]`,
+			wantCode:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			saverMock := mocks.NewURLsSaver(t)
+			producerMock := mocks.NewBatchProducer(t)
+
+			if tc.saveCount > 0 {
+				saverMock.On("SaveURLs", mock.MatchedBy(func(links []models.Link) bool {
+					if len(links) != tc.saveCount {
+						return false
+					}
+					for _, link := range links {
+						if link.UserID != userID {
+							return false
+						}
+					}
+					return true
+				}), tc.query != "").
+					Return(make([]int64, tc.saveCount), tc.saveErrs, tc.saveErr).
+					Once()
+			}
+
+			if tc.audit > 0 {
+				args := []interface{}{mock.Anything}
+				for i := 0; i < tc.audit; i++ {
+					args = append(args, mock.MatchedBy(func(m kafka.Message) bool {
+						return m.Value.(map[string]interface{})["type"] == kafka.EventLinkBlocked
+					}))
+				}
+				producerMock.On("PublishBatch", args...).Return(nil).Once()
+			}
+
+			if tc.publish > 0 {
+				args := []interface{}{mock.Anything}
+				for i := 0; i < tc.publish; i++ {
+					args = append(args, mock.Anything)
+				}
+				producerMock.On("PublishBatch", args...).Return(nil).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/url/batch"+tc.query, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			// the lookup of every domain is made once per batch
+			domainsMock := savemocks.NewDomainLookup(t)
+			if strings.Contains(tc.body, "domain") {
+				domainsMock.On("Domain", "go.acme.com").Return(models.Domain{UserID: userID, Host: "go.acme.com"}, nil).Once()
+				domainsMock.On("Domain", "other.com").Return(models.Domain{}, storage.ErrDomainNotFound).Once()
+			}
+
+			rr := httptest.NewRecorder()
+			New(slogdiscard.NewDiscardLogger(), saverMock, producerMock, aliases, alias.Random{Length: 7}, policy, noTemplates(t), domainsMock).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.wantResults, got.Results)
+		})
+	}
+}
+
+func TestBatchHandlerMultipart(t *testing.T) {
+	saverMock := mocks.NewURLsSaver(t)
+	producerMock := mocks.NewBatchProducer(t)
+
+	saverMock.On("SaveURLs", mock.MatchedBy(func(links []models.Link) bool {
+		return len(links) == 1 && links[0].URL == "https://a.com" && len(links[0].Alias) == 7
+	}), false).Return([]int64{1}, []error{nil}, nil).Once()
+	producerMock.On("PublishBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "links.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("url\nhttps://a.com\n"))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/url/batch", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	New(slogdiscard.NewDiscardLogger(), saverMock, producerMock, aliases, alias.Random{Length: 7}, policy, noTemplates(t), savemocks.NewDomainLookup(t)).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var got Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, 1, got.Created)
+}
+
+func TestBatchHandlerTemplates(t *testing.T) {
+	saverMock := mocks.NewURLsSaver(t)
+	producerMock := mocks.NewBatchProducer(t)
+	templatesMock := savemocks.NewUTMTemplates(t)
+
+	// every template is looked up once per batch
+	templatesMock.On("DefaultUTMTemplate", int64(userID)).
+		Return(models.UTMTemplate{ID: 1, UTM: models.UTM{Source: "newsletter"}}, nil).
+		Once()
+	templatesMock.On("UTMTemplate", int64(userID), int64(3)).
+		Return(models.UTMTemplate{ID: 3, UTM: models.UTM{Source: "ads", Medium: "cpc"}}, nil).
+		Once()
+	templatesMock.On("UTMTemplate", int64(userID), int64(9)).
+		Return(models.UTMTemplate{}, storage.ErrTemplateNotFound).
+		Once()
+
+	saverMock.On("SaveURLs", mock.MatchedBy(func(links []models.Link) bool {
+		return len(links) == 3 &&
+			links[0].URL == "https://a.com?utm_source=newsletter" &&
+			links[1].URL == "https://b.com?utm_medium=cpc&utm_source=ads" &&
+			links[2].URL == "https://c.com?utm_source=newsletter"
+	}), false).Return([]int64{1, 2, 3}, []error{nil, nil, nil}, nil).Once()
+	producerMock.On("PublishBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	body := `[{"url":"https://a.com","alias":"a"},{"url":"https://b.com","alias":"b","utm_template_id":3},` +
+		`{"url":"https://c.com","alias":"c"},{"url":"https://d.com","alias":"d","utm_template_id":9}]`
+
+	req := httptest.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	New(slogdiscard.NewDiscardLogger(), saverMock, producerMock, aliases, alias.Random{Length: 7}, policy, templatesMock, savemocks.NewDomainLookup(t)).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var got Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, 3, got.Created)
+	require.Equal(t, "template not found", got.Results[3].Error)
+}
+
+// noTemplates is a user without campaign templates
+func noTemplates(t *testing.T) *savemocks.UTMTemplates {
+	t.Helper()
+
+	templatesMock := savemocks.NewUTMTemplates(t)
+	templatesMock.On("DefaultUTMTemplate", mock.Anything).Return(models.UTMTemplate{}, storage.ErrTemplateNotFound).Maybe()
+
+	return templatesMock
+}
+
+func TestBatchHandlerTooManyPasswords(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("url,alias,password\n")
+	for i := 0; i <= maxPasswords; i++ {
+		fmt.Fprintf(&body, "https://a.com/%d,a%d,secret\n", i, i)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/url/batch?atomic=true", strings.NewReader(body.String()))
+	req.Header.Set("Content-Type", "text/csv")
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	// the batch is rejected before any password is hashed
+	rr := httptest.NewRecorder()
+	New(slogdiscard.NewDiscardLogger(), mocks.NewURLsSaver(t), mocks.NewBatchProducer(t), aliases, alias.Random{Length: 7}, policy, noTemplates(t), savemocks.NewDomainLookup(t)).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var got Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, errTooManyPasswords.Error(), got.Results[maxPasswords].Error)
+	require.Equal(t, "not saved", got.Results[maxPasswords-1].Error)
+}
+
+func TestBatchHandlerTags(t *testing.T) {
+	saverMock := mocks.NewURLsSaver(t)
+	producerMock := mocks.NewBatchProducer(t)
+
+	saverMock.On("SaveURLs", mock.MatchedBy(func(links []models.Link) bool {
+		return len(links) == 2 &&
+			slices.Equal(links[0].Tags, []string{"promo", "spring"}) &&
+			len(links[1].Tags) == 0
+	}), false).Return([]int64{1, 2}, []error{nil, nil}, nil).Once()
+	producerMock.On("PublishBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	body := `[{"url":"https://a.com","alias":"a","tags":[" promo","spring","promo",""]},{"url":"https://b.com","alias":"b"}]`
+
+	req := httptest.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	New(slogdiscard.NewDiscardLogger(), saverMock, producerMock, aliases, alias.Random{Length: 7}, policy, noTemplates(t), savemocks.NewDomainLookup(t)).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestBatchHandlerFolder(t *testing.T) {
+	saverMock := mocks.NewURLsSaver(t)
+	producerMock := mocks.NewBatchProducer(t)
+
+	saverMock.On("SaveURLs", mock.MatchedBy(func(links []models.Link) bool {
+		return len(links) == 2 &&
+			links[0].FolderID != nil && *links[0].FolderID == 3 &&
+			links[1].FolderID != nil && *links[1].FolderID == 9
+	}), false).Return([]int64{1, 0}, []error{nil, storage.ErrFolderNotFound}, nil).Once()
+	producerMock.On("PublishBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	body := `[{"url":"https://a.com","alias":"a","folder_id":3},{"url":"https://b.com","alias":"b","folder_id":9}]`
+
+	req := httptest.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	New(slogdiscard.NewDiscardLogger(), saverMock, producerMock, aliases, alias.Random{Length: 7}, policy, noTemplates(t), savemocks.NewDomainLookup(t)).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var got Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, 1, got.Created)
+	require.Empty(t, got.Results[0].Error)
+	require.Equal(t, "folder not found", got.Results[1].Error)
+}
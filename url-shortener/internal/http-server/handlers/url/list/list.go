@@ -0,0 +1,165 @@
+package list
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+)
+
+type Response struct {
+	resp.Response
+	Links      []models.Link `json:"links"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+//go:generate mockery --name=URLLister --dir=. --output=./mocks --filename=url_lister_mock.go --outpkg=mocks
+type URLLister interface {
+	ListURLs(filter models.LinkFilter) ([]models.Link, error)
+}
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func New(log *slog.Logger, urlLister URLLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.list.New"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		filter, err := parseFilter(r)
+		if err != nil {
+			log.Error("invalid query", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
+		}
+		filter.UserID = int64(userID)
+
+		// one extra row tells whether there is a next page
+		limit := filter.Limit
+		filter.Limit++
+
+		links, err := urlLister.ListURLs(filter)
+		if err != nil {
+			log.Error("failed to list urls", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to list URLs"))
+			return
+		}
+
+		var next string
+		if len(links) > limit {
+			links = links[:limit]
+			last := links[len(links)-1]
+			next = EncodeCursor(models.LinkCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		}
+
+		log.Info("urls listed", slog.Int("count", len(links)))
+
+		resp.NewJSON(w, r, http.StatusOK, Response{
+			Response:   resp.OK(),
+			Links:      links,
+			NextCursor: next,
+		})
+	}
+}
+
+func parseFilter(r *http.Request) (models.LinkFilter, error) {
+	q := r.URL.Query()
+
+	filter := models.LinkFilter{
+		AliasPrefix: q.Get("alias_prefix"),
+		Domain:      models.NormalizeHost(strings.TrimSpace(q.Get("domain"))),
+		TargetHost:  strings.TrimSpace(q.Get("target_host")),
+		Tag:         strings.TrimSpace(q.Get("tag")),
+		Desc:        true,
+		Limit:       defaultLimit,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		filter.Limit = limit
+	}
+
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		filter.Desc = false
+	default:
+		return filter, errors.New("order must be asc or desc")
+	}
+
+	if v := q.Get("folder_id"); v != "" {
+		folderID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || folderID < 1 {
+			return filter, errors.New("folder_id must be a positive integer")
+		}
+		filter.FolderID = &folderID
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := DecodeCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &cursor
+	}
+
+	return filter, nil
+}
+
+// EncodeCursor packs the position of the link into an opaque string
+func EncodeCursor(c models.LinkCursor) string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor unpacks the cursor created by EncodeCursor
+func DecodeCursor(s string) (models.LinkCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return models.LinkCursor{}, ErrInvalidCursor
+	}
+
+	ts, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return models.LinkCursor{}, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return models.LinkCursor{}, ErrInvalidCursor
+	}
+
+	linkID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return models.LinkCursor{}, ErrInvalidCursor
+	}
+
+	return models.LinkCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: linkID}, nil
+}
@@ -0,0 +1,175 @@
+package list
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/list/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 3
+
+func TestListHandler(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := models.LinkCursor{CreatedAt: now, ID: 10}
+	folderID := int64(3)
+
+	links := func(n int) []models.Link {
+		res := make([]models.Link, n)
+		for i := range res {
+			res[i] = models.Link{ID: int64(i + 1), Alias: "a", URL: "https://example.com", UserID: userID, CreatedAt: now}
+		}
+		return res
+	}
+
+	cases := []struct {
+		name       string
+		query      string
+		wantFilter models.LinkFilter
+		mockLinks  []models.Link
+		mockError  error
+		wantCount  int
+		wantNext   bool
+		respError  string
+		wantCode   int
+	}{
+		{
+			name:       "Defaults",
+			wantFilter: models.LinkFilter{UserID: userID, Desc: true, Limit: defaultLimit + 1},
+			mockLinks:  links(3),
+			wantCount:  3,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:  "Filters and next page",
+			query: "?limit=2&order=asc&alias_prefix=promo&target_host=Example.com&cursor=" + EncodeCursor(cursor),
+			wantFilter: models.LinkFilter{
+				UserID:      userID,
+				AliasPrefix: "promo",
+				TargetHost:  "Example.com",
+				Limit:       3,
+				After:       &cursor,
+			},
+			mockLinks: links(3),
+			wantCount: 2,
+			wantNext:  true,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "Invalid limit",
+			query:     "?limit=1000",
+			respError: "limit must be between 1 and 100",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Invalid cursor",
+			query:     "?cursor=not-a-cursor!",
+			respError: ErrInvalidCursor.Error(),
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:  "Tag and folder",
+			query: "?tag=promo&folder_id=3",
+			wantFilter: models.LinkFilter{
+				UserID:   userID,
+				Tag:      "promo",
+				FolderID: &folderID,
+				Desc:     true,
+				Limit:    defaultLimit + 1,
+			},
+			mockLinks: links(1),
+			wantCount: 1,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:  "Short domain",
+			query: "?domain=Go.Example.com.",
+			wantFilter: models.LinkFilter{
+				UserID: userID,
+				Domain: "go.example.com",
+				Desc:   true,
+				Limit:  defaultLimit + 1,
+			},
+			mockLinks: links(1),
+			wantCount: 1,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "Invalid folder",
+			query:     "?folder_id=x",
+			respError: "folder_id must be a positive integer",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:       "Storage error",
+			wantFilter: models.LinkFilter{UserID: userID, Desc: true, Limit: defaultLimit + 1},
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to list URLs",
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			listerMock := mocks.NewURLLister(t)
+
+			if tc.wantCode != http.StatusBadRequest {
+				listerMock.On("ListURLs", mock.MatchedBy(func(f models.LinkFilter) bool {
+					if (f.After == nil) != (tc.wantFilter.After == nil) {
+						return false
+					}
+					if f.After != nil && (!f.After.CreatedAt.Equal(tc.wantFilter.After.CreatedAt) || f.After.ID != tc.wantFilter.After.ID) {
+						return false
+					}
+					if (f.FolderID == nil) != (tc.wantFilter.FolderID == nil) {
+						return false
+					}
+					if f.FolderID != nil && *f.FolderID != *tc.wantFilter.FolderID {
+						return false
+					}
+					want := tc.wantFilter
+					f.After, want.After = nil, nil
+					f.FolderID, want.FolderID = nil, nil
+					return f == want
+				})).
+					Return(tc.mockLinks, tc.mockError).
+					Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/url"+tc.query, nil)
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			New(slogdiscard.NewDiscardLogger(), listerMock).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var body Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			require.Equal(t, tc.respError, body.Error)
+			require.Len(t, body.Links, tc.wantCount)
+			require.Equal(t, tc.wantNext, body.NextCursor != "")
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := models.LinkCursor{CreatedAt: time.Now().UTC(), ID: 42}
+
+	got, err := DecodeCursor(EncodeCursor(c))
+	require.NoError(t, err)
+	require.True(t, c.CreatedAt.Equal(got.CreatedAt))
+	require.Equal(t, c.ID, got.ID)
+}
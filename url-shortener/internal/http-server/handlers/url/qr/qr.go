@@ -0,0 +1,235 @@
+package qr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/qr"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+//go:generate mockery --name=LinkGetter --dir=. --output=./mocks --filename=link_getter_mock.go --outpkg=mocks
+type LinkGetter interface {
+	GetLink(alias string) (models.Link, error)
+}
+
+const (
+	defaultSize   = 256
+	minSize       = 64
+	maxSize       = 2048
+	defaultMargin = 4
+	maxMargin     = 16
+)
+
+// New renders the QR code of the full short URL of the alias.
+// baseURL is the public address of the service, logo may be nil when it is not configured
+func New(log *slog.Logger, getter LinkGetter, baseURL string, logo image.Image) http.HandlerFunc {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	scheme := "https"
+	if u, err := url.Parse(baseURL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+
+	// the logo takes part in the ETag so that replacing it invalidates cached codes
+	var logoSum string
+	if logo != nil {
+		pix := image.NewRGBA(logo.Bounds())
+		draw.Draw(pix, pix.Bounds(), logo, logo.Bounds().Min, draw.Src)
+		sum := sha256.Sum256(pix.Pix)
+		logoSum = hex.EncodeToString(sum[:])
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.qr.New"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("alias is empty"))
+			return
+		}
+
+		format, opts, withLogo, err := parseOptions(r)
+		if err != nil {
+			log.Error("invalid query", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
+		}
+
+		if withLogo {
+			if logo == nil {
+				log.Error("logo is not configured")
+				resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("logo is not available"))
+				return
+			}
+			opts.Logo = logo
+			// the logo hides part of the modules, so they must be recoverable
+			opts.Level = qrcode.Highest
+		}
+
+		link, err := getter.GetLink(alias)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("alias not found", slog.String("alias", alias))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to get link", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		// links of custom domains are served from their own host with the scheme of the service
+		content := baseURL + "/" + link.Alias
+		if link.Domain != "" {
+			content = scheme + "://" + link.Domain + "/" + link.Alias
+		}
+
+		// the image depends only on the short URL and options, never on the target
+		etag := entityTag(content, format, opts, logoSum)
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+
+		if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		var body []byte
+		if format == "svg" {
+			body, err = qr.SVG(content, opts)
+			w.Header().Set("Content-Type", "image/svg+xml")
+		} else {
+			body, err = qr.PNG(content, opts)
+			w.Header().Set("Content-Type", "image/png")
+		}
+		if errors.Is(err, qr.ErrSizeTooSmall) {
+			w.Header().Del("ETag")
+			log.Error("size too small", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("size is too small for the code"))
+			return
+		}
+		if err != nil {
+			w.Header().Del("ETag")
+			log.Error("failed to render qr code", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to render QR code"))
+			return
+		}
+
+		log.Info("qr code rendered", slog.String("alias", alias), slog.String("format", format))
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}
+}
+
+// parseOptions reads format, size, margin, level, fg, bg and logo query parameters
+func parseOptions(r *http.Request) (string, qr.Options, bool, error) {
+	q := r.URL.Query()
+
+	opts := qr.Options{
+		Size:       defaultSize,
+		Margin:     defaultMargin,
+		Level:      qrcode.Medium,
+		Foreground: color.RGBA{A: 0xff},
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+
+	format := strings.ToLower(q.Get("format"))
+	switch format {
+	case "":
+		format = "png"
+	case "png", "svg":
+	default:
+		return "", opts, false, errors.New("format must be png or svg")
+	}
+
+	if v := q.Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < minSize || size > maxSize {
+			return "", opts, false, fmt.Errorf("size must be between %d and %d", minSize, maxSize)
+		}
+		opts.Size = size
+	}
+
+	if v := q.Get("margin"); v != "" {
+		margin, err := strconv.Atoi(v)
+		if err != nil || margin < 0 || margin > maxMargin {
+			return "", opts, false, fmt.Errorf("margin must be between 0 and %d", maxMargin)
+		}
+		opts.Margin = margin
+	}
+
+	if v := q.Get("level"); v != "" {
+		level, err := qr.ParseLevel(v)
+		if err != nil {
+			return "", opts, false, errors.New("level must be one of L, M, Q, H")
+		}
+		opts.Level = level
+	}
+
+	if v := q.Get("fg"); v != "" {
+		c, err := qr.ParseColor(v)
+		if err != nil {
+			return "", opts, false, errors.New("fg must be a hex color")
+		}
+		opts.Foreground = c
+	}
+
+	if v := q.Get("bg"); v != "" {
+		c, err := qr.ParseColor(v)
+		if err != nil {
+			return "", opts, false, errors.New("bg must be a hex color")
+		}
+		opts.Background = c
+	}
+
+	var withLogo bool
+	if v := q.Get("logo"); v != "" {
+		var err error
+		withLogo, err = strconv.ParseBool(v)
+		if err != nil {
+			return "", opts, false, errors.New("logo must be true or false")
+		}
+	}
+
+	return format, opts, withLogo, nil
+}
+
+// entityTag identifies the rendered image by everything it is built from
+func entityTag(content, format string, opts qr.Options, logoSum string) string {
+	if opts.Logo == nil {
+		logoSum = ""
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%d|%d|%s|%s|%s",
+		content, format, opts.Size, opts.Margin, opts.Level,
+		qr.Hex(opts.Foreground), qr.Hex(opts.Background), logoSum,
+	)))
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
@@ -0,0 +1,142 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/qr/mocks"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQRHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		alias       string
+		query       string
+		mockError   error
+		skipLookup  bool
+		respError   string
+		wantCode    int
+		contentType string
+	}{
+		{
+			name:        "PNG by default",
+			alias:       "promo",
+			wantCode:    http.StatusOK,
+			contentType: "image/png",
+		},
+		{
+			name:        "SVG with colors",
+			alias:       "promo",
+			query:       "?format=svg&fg=%23336699&bg=fff&level=H&margin=0",
+			wantCode:    http.StatusOK,
+			contentType: "image/svg+xml",
+		},
+		{
+			name:        "PNG with logo",
+			alias:       "promo",
+			query:       "?size=512&logo=true",
+			wantCode:    http.StatusOK,
+			contentType: "image/png",
+		},
+		{
+			name:       "Invalid size",
+			alias:      "promo",
+			query:      "?size=10",
+			skipLookup: true,
+			respError:  "size must be between 64 and 2048",
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid color",
+			alias:      "promo",
+			query:      "?fg=blue",
+			skipLookup: true,
+			respError:  "fg must be a hex color",
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:      "Alias not found",
+			alias:     "missing",
+			mockError: storage.ErrURLNotFound,
+			respError: "alias not found",
+			wantCode:  http.StatusNotFound,
+		},
+	}
+
+	logo := image.NewRGBA(image.Rect(0, 0, 8, 8))
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			getterMock := mocks.NewLinkGetter(t)
+			if !tc.skipLookup {
+				getterMock.On("GetLink", tc.alias).Return(models.Link{Alias: tc.alias}, tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/url/{alias}/qr", New(slogdiscard.NewDiscardLogger(), getterMock, "https://sho.rt/", logo))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/"+tc.alias+"/qr"+tc.query, nil))
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			if tc.wantCode != http.StatusOK {
+				var got resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+				require.Equal(t, tc.respError, got.Error)
+				return
+			}
+
+			require.Equal(t, tc.contentType, rr.Header().Get("Content-Type"))
+			require.NotEmpty(t, rr.Header().Get("ETag"))
+
+			if tc.contentType == "image/png" {
+				img, err := png.Decode(bytes.NewReader(rr.Body.Bytes()))
+				require.NoError(t, err)
+				require.Equal(t, img.Bounds().Dx(), img.Bounds().Dy())
+			} else {
+				require.True(t, strings.HasPrefix(rr.Body.String(), "<svg"))
+				require.Contains(t, rr.Body.String(), "#336699")
+			}
+		})
+	}
+}
+
+func TestQRHandlerNotModified(t *testing.T) {
+	getterMock := mocks.NewLinkGetter(t)
+	getterMock.On("GetLink", "promo").Return(models.Link{Alias: "promo"}, nil).Twice()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/qr", New(slogdiscard.NewDiscardLogger(), getterMock, "https://sho.rt", nil))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/promo/qr?size=128", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/url/promo/qr?size=128", nil)
+	req.Header.Set("If-None-Match", etag)
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Empty(t, rr.Body.Bytes())
+}
@@ -0,0 +1,277 @@
+package rules
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/rules"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+// maxRules keeps the evaluation on every redirect cheap
+const maxRules = 50
+
+type Request struct {
+	// Position is 1-based, zero appends a new rule or keeps the position of an updated one
+	Position int    `json:"position" validate:"gte=0"`
+	Platform string `json:"platform,omitempty" validate:"omitempty,oneof=ios android desktop"`
+	Language string `json:"language,omitempty" validate:"omitempty,bcp47_language_tag"`
+	Country  string `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	TimeFrom string `json:"time_from,omitempty" validate:"omitempty,datetime=15:04"`
+	TimeTo   string `json:"time_to,omitempty" validate:"omitempty,datetime=15:04"`
+	Timezone string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+	URL      string `json:"url" validate:"required,url"`
+}
+
+type Response struct {
+	resp.Response
+	Rule *models.Rule `json:"rule,omitempty"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Rules []models.Rule `json:"rules"`
+}
+
+//go:generate mockery --name=RuleStorage --dir=. --output=./mocks --filename=rule_storage_mock.go --outpkg=mocks
+type RuleStorage interface {
+	URLOwner(alias string) (int64, error)
+	LinkRules(alias string) ([]models.Rule, error)
+	AddRule(alias string, rule models.Rule) (int64, error)
+	UpdateRule(alias string, rule models.Rule) error
+	DeleteRule(alias string, ruleID int64) error
+}
+
+// List returns redirect rules of the alias in evaluation order
+func List(log *slog.Logger, ruleStorage RuleStorage, adminChecker access.AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.rules.List"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, ruleStorage, adminChecker)
+		if !ok {
+			return
+		}
+
+		list, err := ruleStorage.LinkRules(alias)
+		if err != nil {
+			log.Error("failed to get rules", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+			return
+		}
+
+		resp.NewJSON(w, r, http.StatusOK, ListResponse{
+			Response: resp.OK(),
+			Rules:    list,
+		})
+	}
+}
+
+// Create adds a redirect rule to the alias
+func Create(log *slog.Logger, ruleStorage RuleStorage, adminChecker access.AdminChecker, checker urlpolicy.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.rules.Create"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, ruleStorage, adminChecker)
+		if !ok {
+			return
+		}
+
+		rule, ok := decode(w, r, log, checker)
+		if !ok {
+			return
+		}
+
+		existing, err := ruleStorage.LinkRules(alias)
+		if err != nil {
+			log.Error("failed to get rules", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+			return
+		}
+		if len(existing) >= maxRules {
+			log.Warn("too many rules", slog.String("alias", alias))
+			resp.NewJSON(w, r, http.StatusUnprocessableEntity, resp.Error("too many rules"))
+			return
+		}
+
+		rule.ID, err = ruleStorage.AddRule(alias, rule)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to add rule", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add rule"))
+			return
+		}
+
+		log.Info("rule added", slog.String("alias", alias), slog.Int64("rule_id", rule.ID))
+
+		resp.NewJSON(w, r, http.StatusCreated, Response{
+			Response: resp.OK(),
+			Rule:     &rule,
+		})
+	}
+}
+
+// Update replaces the conditions and target of a rule
+func Update(log *slog.Logger, ruleStorage RuleStorage, adminChecker access.AdminChecker, checker urlpolicy.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.rules.Update"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		ruleID, err := strconv.ParseInt(chi.URLParam(r, "rule"), 10, 64)
+		if err != nil {
+			log.Error("invalid rule id", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid rule id"))
+			return
+		}
+
+		alias, _, ok := access.Authorize(w, r, log, ruleStorage, adminChecker)
+		if !ok {
+			return
+		}
+
+		rule, ok := decode(w, r, log, checker)
+		if !ok {
+			return
+		}
+		rule.ID = ruleID
+
+		err = ruleStorage.UpdateRule(alias, rule)
+		switch {
+		case errors.Is(err, storage.ErrRuleNotFound):
+			log.Error("rule not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("rule not found"))
+			return
+		case err != nil:
+			log.Error("failed to update rule", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to update rule"))
+			return
+		}
+
+		log.Info("rule updated", slog.String("alias", alias), slog.Int64("rule_id", ruleID))
+
+		resp.NewJSON(w, r, http.StatusOK, Response{
+			Response: resp.OK(),
+			Rule:     &rule,
+		})
+	}
+}
+
+// Delete removes a rule of the alias
+func Delete(log *slog.Logger, ruleStorage RuleStorage, adminChecker access.AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.rules.Delete"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		ruleID, err := strconv.ParseInt(chi.URLParam(r, "rule"), 10, 64)
+		if err != nil {
+			log.Error("invalid rule id", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid rule id"))
+			return
+		}
+
+		alias, _, ok := access.Authorize(w, r, log, ruleStorage, adminChecker)
+		if !ok {
+			return
+		}
+
+		err = ruleStorage.DeleteRule(alias, ruleID)
+		switch {
+		case errors.Is(err, storage.ErrRuleNotFound):
+			log.Error("rule not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("rule not found"))
+			return
+		case err != nil:
+			log.Error("failed to delete rule", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to delete rule"))
+			return
+		}
+
+		log.Info("rule deleted", slog.String("alias", alias), slog.Int64("rule_id", ruleID))
+
+		resp.NewJSON(w, r, http.StatusOK, resp.OK())
+	}
+}
+
+// decode reads and validates the rule from the request body
+// and writes the error response when it is invalid
+func decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, checker urlpolicy.Checker) (models.Rule, bool) {
+	var req Request
+
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request body"))
+		return models.Rule{}, false
+	}
+
+	req.Platform = strings.ToLower(req.Platform)
+	req.Country = strings.ToUpper(req.Country)
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+
+		log.Error("invalid request", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+		return models.Rule{}, false
+	}
+
+	rule := models.Rule{
+		Position: req.Position,
+		Platform: req.Platform,
+		Language: req.Language,
+		Country:  req.Country,
+		TimeFrom: req.TimeFrom,
+		TimeTo:   req.TimeTo,
+		Timezone: req.Timezone,
+		URL:      req.URL,
+	}
+
+	if err := rules.Validate(rule); err != nil {
+		log.Error("invalid rule", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+		return models.Rule{}, false
+	}
+
+	if err := checker.Check(r.Context(), rule.URL); err != nil {
+		log.Warn("target URL rejected", slog.String("url", rule.URL), sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+		return models.Rule{}, false
+	}
+
+	return rule, true
+}
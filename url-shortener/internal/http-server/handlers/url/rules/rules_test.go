@@ -0,0 +1,202 @@
+package rules
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/rules/mocks"
+	accessmocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access/mocks"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	policymocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 3
+
+func TestCreateHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		ownerID   int64
+		blocked   error
+		saved     bool
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "App Store for iPhones",
+			body:     `{"platform":"iOS","url":"https://apps.apple.com/app/id1"}`,
+			ownerID:  userID,
+			saved:    true,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Country and night window",
+			body:     `{"country":"de","time_from":"22:00","time_to":"06:00","timezone":"Europe/Berlin","url":"https://example.de"}`,
+			ownerID:  userID,
+			saved:    true,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:      "Unknown platform",
+			body:      `{"platform":"symbian","url":"https://example.com"}`,
+			ownerID:   userID,
+			respError: "field Platform must be one of ios android desktop",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "No conditions",
+			body:      `{"url":"https://example.com"}`,
+			ownerID:   userID,
+			respError: "rule must have at least one condition",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Half a window",
+			body:      `{"time_from":"09:00","url":"https://example.com"}`,
+			ownerID:   userID,
+			respError: "time_from and time_to must be set together",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Invalid time",
+			body:      `{"time_from":"9am","time_to":"18:00","url":"https://example.com"}`,
+			ownerID:   userID,
+			respError: "field TimeFrom is not a valid",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Private target",
+			body:      `{"platform":"android","url":"http://10.0.0.1"}`,
+			ownerID:   userID,
+			blocked:   urlpolicy.ErrDeniedAddress,
+			respError: "URL points to a private address",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Not owner",
+			body:      `{"platform":"ios","url":"https://apps.apple.com/app/id1"}`,
+			ownerID:   userID + 1,
+			respError: "forbidden",
+			wantCode:  http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewRuleStorage(t)
+			adminMock := accessmocks.NewAdminChecker(t)
+			checkerMock := policymocks.NewChecker(t)
+
+			storageMock.On("URLOwner", "app").Return(tc.ownerID, nil).Once()
+			adminMock.On("IsAdmin", mock.Anything, int64(userID)).Return(false, nil).Maybe()
+			checkerMock.On("Check", mock.Anything, mock.AnythingOfType("string")).Return(tc.blocked).Maybe()
+
+			if tc.saved {
+				storageMock.On("LinkRules", "app").Return([]models.Rule{}, nil).Once()
+				storageMock.On("AddRule", "app", mock.MatchedBy(func(rule models.Rule) bool {
+					// normalized before saving
+					return rule.Platform == "ios" || rule.Country == "DE"
+				})).Return(int64(10), nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/rules", Create(slogdiscard.NewDiscardLogger(), storageMock, adminMock, checkerMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/url/app/rules", strings.NewReader(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+
+			if tc.saved {
+				require.Equal(t, int64(10), got.Rule.ID)
+			}
+		})
+	}
+}
+
+func TestUpdateHandler(t *testing.T) {
+	storageMock := mocks.NewRuleStorage(t)
+	checkerMock := policymocks.NewChecker(t)
+
+	storageMock.On("URLOwner", "app").Return(int64(userID), nil).Twice()
+	checkerMock.On("Check", mock.Anything, "https://example.com").Return(nil).Twice()
+	storageMock.On("UpdateRule", "app", mock.MatchedBy(func(rule models.Rule) bool { return rule.ID == 7 })).
+		Return(nil).Once()
+	storageMock.On("UpdateRule", "app", mock.MatchedBy(func(rule models.Rule) bool { return rule.ID == 8 })).
+		Return(storage.ErrRuleNotFound).Once()
+
+	r := chi.NewRouter()
+	r.Put("/url/{alias}/rules/{rule}", Update(slogdiscard.NewDiscardLogger(), storageMock, accessmocks.NewAdminChecker(t), checkerMock))
+
+	for id, wantCode := range map[string]int{"7": http.StatusOK, "8": http.StatusNotFound, "x": http.StatusBadRequest} {
+		req := httptest.NewRequest(http.MethodPut, "/url/app/rules/"+id, strings.NewReader(`{"language":"de","url":"https://example.com"}`))
+		req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, wantCode, rr.Code, id)
+	}
+}
+
+func TestListAndDeleteHandlers(t *testing.T) {
+	storageMock := mocks.NewRuleStorage(t)
+	adminMock := accessmocks.NewAdminChecker(t)
+
+	// the admin manages a link of another user
+	storageMock.On("URLOwner", "app").Return(int64(userID+1), nil).Twice()
+	adminMock.On("IsAdmin", mock.Anything, int64(userID)).Return(true, nil).Twice()
+	storageMock.On("LinkRules", "app").
+		Return([]models.Rule{{ID: 1, Position: 1, Platform: "ios", URL: "https://apps.apple.com/app/id1"}}, nil).
+		Once()
+	storageMock.On("DeleteRule", "app", int64(1)).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/rules", List(slogdiscard.NewDiscardLogger(), storageMock, adminMock))
+	r.Delete("/url/{alias}/rules/{rule}", Delete(slogdiscard.NewDiscardLogger(), storageMock, adminMock))
+
+	req := httptest.NewRequest(http.MethodGet, "/url/app/rules", nil)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var list ListResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	require.Len(t, list.Rules, 1)
+
+	req = httptest.NewRequest(http.MethodDelete, "/url/app/rules/1", nil)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var got resp.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, resp.StatusOk, got.Status)
+}
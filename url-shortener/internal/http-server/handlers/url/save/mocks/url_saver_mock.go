@@ -2,27 +2,51 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	models "github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // URLSaver is an autogenerated mock type for the URLSaver type
 type URLSaver struct {
 	mock.Mock
 }
 
-// SaveURL provides a mock function with given fields: urlToSave, alias
-func (_m *URLSaver) SaveURL(urlToSave string, alias string) (int64, error) {
-	ret := _m.Called(urlToSave, alias)
+// SaveURL provides a mock function with given fields: link
+func (_m *URLSaver) SaveURL(link models.Link) (int64, error) {
+	ret := _m.Called(link)
 
 	var r0 int64
-	if rf, ok := ret.Get(0).(func(string, string) int64); ok {
-		r0 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(0).(func(models.Link) int64); ok {
+		r0 = rf(link)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(1).(func(models.Link) error); ok {
+		r1 = rf(link)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserLinkByURL provides a mock function with given fields: userID, domain, url
+func (_m *URLSaver) UserLinkByURL(userID int64, domain string, url string) (models.Link, error) {
+	ret := _m.Called(userID, domain, url)
+
+	var r0 models.Link
+	if rf, ok := ret.Get(0).(func(int64, string, string) models.Link); ok {
+		r0 = rf(userID, domain, url)
+	} else {
+		r0 = ret.Get(0).(models.Link)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64, string, string) error); ok {
+		r1 = rf(userID, domain, url)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -6,22 +6,61 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/alias"
 	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/deeplink"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
-	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/random"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Request struct {
 	URL   string `json:"url" validate:"required,url"`
-	Alias string `json:"alias,omitempty"`
+	Alias string `json:"alias,omitempty" validate:"omitempty,alias"`
+	// Domain is a verified custom domain of the user, the alias is unique on it only
+	Domain    string     `json:"domain,omitempty" validate:"omitempty,fqdn"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" validate:"omitempty,gt"`
+	// NotBefore schedules the launch, the link does not redirect earlier
+	NotBefore *time.Time `json:"not_before,omitempty" validate:"omitempty,gt"`
+	MaxClicks *int64     `json:"max_clicks,omitempty" validate:"omitempty,gt=0"`
+	Password  string     `json:"password,omitempty" validate:"omitempty,min=4,password"`
+	Tags      []string   `json:"tags,omitempty" validate:"max=20,dive,max=50"`
+	FolderID  *int64     `json:"folder_id,omitempty" validate:"omitempty,gt=0"`
+	// RedirectCode overrides the default status code of the service
+	RedirectCode int  `json:"redirect_code,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	ForwardQuery bool `json:"forward_query,omitempty"`
+	ForwardPath  bool `json:"forward_path,omitempty"`
+	// Title and Description are shown on the preview page
+	Title         string `json:"title,omitempty" validate:"max=200"`
+	Description   string `json:"description,omitempty" validate:"max=1000"`
+	AlwaysPreview bool   `json:"always_preview,omitempty"`
+	// DeepLink opens the app on mobile instead of the URL
+	DeepLink *models.DeepLink `json:"deep_link,omitempty"`
+	// UTM parameters are added to the URL, missing ones come from the template
+	UTM           *models.UTM `json:"utm,omitempty"`
+	UTMTemplateID *int64      `json:"utm_template_id,omitempty" validate:"omitempty,gt=0"`
+}
+
+// LogValue hides the link password from logs
+func (r Request) LogValue() slog.Value {
+	type plain Request
+
+	p := plain(r)
+	if p.Password != "" {
+		p.Password = "***"
+	}
+
+	return slog.AnyValue(p)
 }
 
 type Response struct {
@@ -29,19 +68,71 @@ type Response struct {
 	Alias string `json:"alias,omitempty"`
 }
 
+// ExistingResponse is returned when the user has already shortened the URL
+type ExistingResponse struct {
+	resp.AliasResponse
+	Existing bool `json:"existing"`
+}
+
 //go:generate mockery --name=URLSaver --dir=. --output=./mocks --filename=url_saver_mock.go --outpkg=mocks
 type URLSaver interface {
-	SaveURL(urlToSave string, alias string) (int64, error)
+	SaveURL(link models.Link) (int64, error)
+	UserLinkByURL(userID int64, domain, url string) (models.Link, error)
 }
 
+//go:generate mockery --name=ProducerProvider --dir=. --output=./mocks --filename=producer_provider_mock.go --outpkg=mocks
 type ProducerProvider interface {
 	Publish(ctx context.Context, key string, value interface{}) error
 	Close() error
 }
 
-const aliasLength = 6
+// AliasGenerator makes aliases for links created without a custom one
+//
+//go:generate mockery --name=AliasGenerator --dir=. --output=./mocks --filename=alias_generator_mock.go --outpkg=mocks
+type AliasGenerator interface {
+	Generate(link *models.Link) error
+}
+
+// UTMTemplates finds the campaign template applied to a new link
+//
+//go:generate mockery --name=UTMTemplates --dir=. --output=./mocks --filename=utm_templates_mock.go --outpkg=mocks
+type UTMTemplates interface {
+	UTMTemplate(userID, templateID int64) (models.UTMTemplate, error)
+	DefaultUTMTemplate(userID int64) (models.UTMTemplate, error)
+}
+
+// DomainLookup finds the verified domain serving a host
+//
+//go:generate mockery --name=DomainLookup --dir=. --output=./mocks --filename=domain_lookup_mock.go --outpkg=mocks
+type DomainLookup interface {
+	Domain(host string) (models.Domain, error)
+}
+
+// ErrDomainNotVerified is returned for domains the user has not verified
+var ErrDomainNotVerified = errors.New("domain is not verified")
+
+// TagPassword is the validation tag of passwords, see Register
+const TagPassword = "password"
+
+// maxPasswordBytes is the longest input bcrypt hashes
+const maxPasswordBytes = 72
+
+// New creates a link, custom aliases are checked against the rules of aliases,
+// missing ones are made by generator and regenerated on collision
+func New(
+	log *slog.Logger,
+	urlSaver URLSaver,
+	producerProvider ProducerProvider,
+	aliases *alias.Validator,
+	generator AliasGenerator,
+	checker urlpolicy.Checker,
+	templates UTMTemplates,
+	domains DomainLookup,
+) http.HandlerFunc {
+	validate := validator.New()
+	aliases.Register(validate)
+	Register(validate)
 
-func New(log *slog.Logger, urlSaver URLSaver, producerProvider *kafka.Producer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.save.New"
 
@@ -73,7 +164,7 @@ func New(log *slog.Logger, urlSaver URLSaver, producerProvider *kafka.Producer)
 		log.Info("request body decoded", slog.Any("request", req))
 
 		// validator for errors struct
-		if err := validator.New().Struct(req); err != nil {
+		if err := validate.Struct(req); err != nil {
 			var validateErr validator.ValidationErrors
 			errors.As(err, &validateErr)
 
@@ -83,26 +174,108 @@ func New(log *slog.Logger, urlSaver URLSaver, producerProvider *kafka.Producer)
 			return
 		}
 
-		// if alias is empty, generate a new alias
-		alias := req.Alias
-		if alias == "" {
-			alias = random.NewRandomString(aliasLength)
+		req.Domain = models.NormalizeHost(req.Domain)
+		if err := CheckDomain(domains, int64(userID), req.Domain); err != nil {
+			if errors.Is(err, ErrDomainNotVerified) {
+				log.Warn("domain is not verified", slog.String("domain", req.Domain))
+				resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+				return
+			}
+
+			log.Error("failed to get domain", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URL"))
+			return
 		}
 
-		id, err := urlSaver.SaveURL(req.URL, alias)
+		tmpl, err := TemplateFor(templates, int64(userID), req.UTMTemplateID)
+		if err != nil {
+			if errors.Is(err, storage.ErrTemplateNotFound) {
+				log.Warn("utm template not found", slog.Any("template_id", req.UTMTemplateID))
+				resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("template not found"))
+				return
+			}
+
+			log.Error("failed to get utm template", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URL"))
+			return
+		}
 
+		var utm models.UTM
+		if req.UTM != nil {
+			utm = *req.UTM
+		}
+
+		req.URL, err = utm.WithDefaults(tmpl.UTM).Apply(req.URL)
+		if err != nil {
+			log.Error("failed to apply utm parameters", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid url"))
+			return
+		}
+
+		if req.DeepLink != nil {
+			if err := deeplink.Check(r.Context(), checker, *req.DeepLink); err != nil {
+				log.Warn("deep link rejected", sl.Err(err))
+				resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+				return
+			}
+		}
+
+		if err := checker.Check(r.Context(), req.URL); err != nil {
+			log.Warn("target URL rejected", slog.String("url", req.URL), sl.Err(err))
+
+			ev := urlpolicy.Event(r, int64(userID), req.URL, err, "create")
+			if err := producerProvider.Publish(ctx, strconv.FormatInt(int64(userID), 10), ev); err != nil {
+				log.Error("failed to send message to Kafka", sl.Err(err))
+			}
+
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
+		}
+
+		link := models.Link{
+			Alias:         req.Alias,
+			Domain:        req.Domain,
+			URL:           req.URL,
+			UserID:        int64(userID),
+			ExpiresAt:     req.ExpiresAt,
+			MaxClicks:     req.MaxClicks,
+			FolderID:      req.FolderID,
+			Tags:          models.NormalizeTags(req.Tags),
+			RedirectCode:  req.RedirectCode,
+			ForwardQuery:  req.ForwardQuery,
+			ForwardPath:   req.ForwardPath,
+			NotBefore:     req.NotBefore,
+			Title:         strings.TrimSpace(req.Title),
+			Description:   strings.TrimSpace(req.Description),
+			AlwaysPreview: req.AlwaysPreview,
+			DeepLink:      req.DeepLink,
+		}
+
+		if req.Password != "" {
+			link.PasswordHash, err = bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+			if err != nil {
+				log.Error("failed to hash password", sl.Err(err))
+				resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URL"))
+				return
+			}
+		}
+
+		id, err := saveLink(urlSaver, aliases, generator, &link, req.Alias == "")
 		if err != nil {
 			switch {
 			case errors.Is(err, storage.ErrURLExists):
-				log.Error("URL already exists", sl.Err(err))
-				resp.NewJSON(w, r, http.StatusConflict, resp.Error("URL already exists"))
+				existing(w, r, log, urlSaver, int64(userID), req)
 				return
 			case errors.Is(err, storage.ErrAliasExists):
 				log.Error("alias already exists", sl.Err(err))
 				resp.NewJSON(w, r, http.StatusConflict, resp.Error("alias already exists"))
 				return
+			case errors.Is(err, storage.ErrFolderNotFound):
+				log.Error("folder not found", sl.Err(err))
+				resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("folder not found"))
+				return
 			default:
-				log.Error("failed to add error")
+				log.Error("failed to add url", sl.Err(err))
 				resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URL"))
 				return
 			}
@@ -113,9 +286,19 @@ func New(log *slog.Logger, urlSaver URLSaver, producerProvider *kafka.Producer)
 			"type":      kafka.EventLinkSaved,
 			"timestamp": time.Now().UTC(),
 			"user_id":   userID,
-			"alias":     alias,
+			"alias":     link.Ref(),
 			"url":       req.URL,
 			"link_id":   id,
+			"tags":      link.Tags,
+		}
+		if link.Domain != "" {
+			ev["domain"] = link.Domain
+		}
+		if link.NotBefore != nil {
+			ev["not_before"] = link.NotBefore
+		}
+		for key, value := range models.ParseUTM(req.URL).Fields() {
+			ev[key] = value
 		}
 
 		err = producerProvider.Publish(ctx, strconv.FormatInt(int64(userID), 10), ev)
@@ -123,6 +306,156 @@ func New(log *slog.Logger, urlSaver URLSaver, producerProvider *kafka.Producer)
 			log.Error("failed to send message to Kafka", sl.Err(err))
 		}
 		log.Info("url added", slog.Int64("id", id))
-		resp.RespOk(w, r, alias)
+		resp.RespOk(w, r, link.Alias)
+	}
+}
+
+// CheckDomain returns ErrDomainNotVerified unless the host is a domain verified by the user.
+// The empty host is the domain of the service and is always allowed
+func CheckDomain(domains DomainLookup, userID int64, host string) error {
+	if host == "" {
+		return nil
+	}
+
+	d, err := domains.Domain(host)
+	if errors.Is(err, storage.ErrDomainNotFound) || err == nil && d.UserID != userID {
+		return ErrDomainNotVerified
+	}
+
+	return err
+}
+
+// Register adds the checks of Request that involve several fields to validate
+func Register(validate *validator.Validate) {
+	validate.RegisterStructValidation(validateSchedule, Request{})
+	_ = validate.RegisterValidation(TagPassword, validatePassword)
+}
+
+// validatePassword limits the password to the bytes bcrypt can hash,
+// max counts runes and lets longer multi-byte passwords through
+func validatePassword(fl validator.FieldLevel) bool {
+	return len(fl.Field().String()) <= maxPasswordBytes
+}
+
+// validateSchedule rejects links that expire before their launch
+func validateSchedule(sl validator.StructLevel) {
+	req := sl.Current().Interface().(Request)
+
+	if req.NotBefore != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.NotBefore) {
+		sl.ReportError(req.ExpiresAt, "ExpiresAt", "ExpiresAt", "gtfield", "NotBefore")
+	}
+}
+
+// existing answers with the link the user already has for the URL. The link is only handed back
+// when it is still active and was created with the options of the request, otherwise the
+// alias comes with a conflict and the client decides what to do with it
+func existing(w http.ResponseWriter, r *http.Request, log *slog.Logger, urlSaver URLSaver, userID int64, req Request) {
+	link, err := urlSaver.UserLinkByURL(userID, req.Domain, req.URL)
+	if err != nil {
+		log.Error("failed to get existing link", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to add URL"))
+		return
+	}
+
+	now := time.Now()
+
+	var reason string
+	switch {
+	case req.Alias != "" && req.Alias != link.Alias:
+		reason = "URL already shortened under another alias"
+	case link.Expired(now) || link.Exhausted() || link.Paused:
+		reason = "URL already shortened by an inactive link"
+	case !sameOptions(link, req):
+		reason = "URL already shortened with other options"
+	}
+
+	if reason != "" {
+		log.Info(reason, slog.String("alias", link.Alias))
+		resp.NewJSON(w, r, http.StatusConflict, ExistingResponse{
+			AliasResponse: resp.AliasResponse{Response: resp.Error("URL already exists"), Alias: link.Alias},
+			Existing:      true,
+		})
+		return
+	}
+
+	log.Info("URL already shortened", slog.String("alias", link.Alias))
+	resp.NewJSON(w, r, http.StatusOK, ExistingResponse{
+		AliasResponse: resp.AliasResponse{Response: resp.OK(), Alias: link.Alias},
+		Existing:      true,
+	})
+}
+
+// sameOptions reports whether the stored link restricts visits the way the request asks.
+// A password cannot be compared with the stored hash cheaply, so protected links never match
+func sameOptions(link models.Link, req Request) bool {
+	if link.Protected || req.Password != "" {
+		return false
+	}
+
+	if link.MaxClicks == nil || req.MaxClicks == nil {
+		if link.MaxClicks != req.MaxClicks {
+			return false
+		}
+	} else if *link.MaxClicks != *req.MaxClicks {
+		return false
+	}
+
+	if link.DeepLink == nil || req.DeepLink == nil {
+		if link.DeepLink != req.DeepLink {
+			return false
+		}
+	} else if *link.DeepLink != *req.DeepLink {
+		return false
+	}
+
+	return sameTime(link.ExpiresAt, req.ExpiresAt) && sameTime(link.NotBefore, req.NotBefore)
+}
+
+// sameTime compares times at the microsecond precision of postgres
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Sub(*b).Abs() < time.Microsecond
+}
+
+// TemplateFor returns the template chosen by the request or the default one of the user.
+// A missing default template is not an error, the zero template adds nothing
+func TemplateFor(templates UTMTemplates, userID int64, templateID *int64) (models.UTMTemplate, error) {
+	if templateID != nil {
+		return templates.UTMTemplate(userID, *templateID)
+	}
+
+	tmpl, err := templates.DefaultUTMTemplate(userID)
+	if errors.Is(err, storage.ErrTemplateNotFound) {
+		return models.UTMTemplate{}, nil
+	}
+
+	return tmpl, err
+}
+
+// saveLink stores the link. When generate is set the alias is made by generator, checked
+// by aliases and made again if it is already taken, up to alias.MaxAttempts times
+func saveLink(
+	urlSaver URLSaver,
+	aliases *alias.Validator,
+	generator AliasGenerator,
+	link *models.Link,
+	generate bool,
+) (int64, error) {
+	for attempt := 1; ; attempt++ {
+		if generate {
+			if err := aliases.Generate(generator, link); err != nil {
+				return 0, err
+			}
+		}
+
+		id, err := urlSaver.SaveURL(*link)
+		if generate && errors.Is(err, storage.ErrAliasExists) && attempt < alias.MaxAttempts {
+			continue
+		}
+
+		return id, err
 	}
 }
@@ -6,16 +6,26 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/lostmyescape/link-shortener/common/kafka"
 	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/alias"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	policymocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy/mocks"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
+const userID = 42
+
 func TestSaveHandler(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -24,6 +34,14 @@ func TestSaveHandler(t *testing.T) {
 		respError string
 		mockError error
 		wantCode  int
+		expiresAt *time.Time
+		notBefore *time.Time
+		maxClicks *int64
+		password  string
+		existing  string
+		stored    models.Link
+		blocked   error
+		redirect  int
 	}{
 		{
 			name:     "Success",
@@ -60,13 +78,175 @@ func TestSaveHandler(t *testing.T) {
 			wantCode:  http.StatusInternalServerError,
 		},
 		{
-			name:      "URL already exists",
+			name:      "URL already exists under another alias",
 			url:       "https://google.com",
 			alias:     "go",
+			existing:  "google",
+			wantCode:  http.StatusConflict,
+			respError: "URL already exists",
+			mockError: storage.ErrURLExists,
+		},
+		{
+			name:      "Same URL again",
+			url:       "https://google.com",
+			existing:  "google",
+			wantCode:  http.StatusOK,
+			mockError: storage.ErrURLExists,
+		},
+		{
+			name:      "Same URL with the same options",
+			url:       "https://google.com",
+			existing:  "google",
+			maxClicks: ptr(int64(100)),
+			stored:    models.Link{MaxClicks: ptr(int64(100)), Clicks: 3},
+			wantCode:  http.StatusOK,
+			mockError: storage.ErrURLExists,
+		},
+		{
+			name:      "Same URL with a password",
+			url:       "https://google.com",
+			existing:  "google",
+			password:  "secret",
+			wantCode:  http.StatusConflict,
+			respError: "URL already exists",
+			mockError: storage.ErrURLExists,
+		},
+		{
+			name:      "Same URL with an expiration",
+			url:       "https://google.com",
+			existing:  "google",
+			expiresAt: ptr(time.Now().Add(time.Hour)),
 			wantCode:  http.StatusConflict,
 			respError: "URL already exists",
 			mockError: storage.ErrURLExists,
 		},
+		{
+			name:      "Same URL of an expired link",
+			url:       "https://google.com",
+			existing:  "google",
+			stored:    models.Link{ExpiresAt: ptr(time.Now().Add(-time.Hour))},
+			wantCode:  http.StatusConflict,
+			respError: "URL already exists",
+			mockError: storage.ErrURLExists,
+		},
+		{
+			name:      "Same URL of an exhausted link",
+			url:       "https://google.com",
+			existing:  "google",
+			stored:    models.Link{MaxClicks: ptr(int64(5)), Clicks: 5},
+			wantCode:  http.StatusConflict,
+			respError: "URL already exists",
+			mockError: storage.ErrURLExists,
+		},
+		{
+			name:      "Same URL of a paused link",
+			url:       "https://google.com",
+			existing:  "google",
+			stored:    models.Link{Paused: true},
+			wantCode:  http.StatusConflict,
+			respError: "URL already exists",
+			mockError: storage.ErrURLExists,
+		},
+		{
+			name:      "Expiration and click limit",
+			url:       "https://google.com",
+			alias:     "campaign",
+			expiresAt: ptr(time.Now().Add(time.Hour)),
+			maxClicks: ptr(int64(100)),
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:     "Permanent redirect",
+			alias:    "docs",
+			url:      "https://example.com/docs",
+			redirect: http.StatusMovedPermanently,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Unsupported redirect code",
+			alias:     "docs",
+			url:       "https://example.com/docs",
+			redirect:  http.StatusSeeOther,
+			respError: "field RedirectCode must be one of 301 302 307 308",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:     "Password",
+			url:      "https://google.com",
+			alias:    "secret",
+			password: "qwerty",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Password longer than bcrypt input",
+			url:       "https://google.com",
+			alias:     "secret",
+			password:  strings.Repeat("я", 40),
+			respError: "field Password must be at most 72 bytes",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Reserved alias",
+			url:       "https://google.com",
+			alias:     "Login",
+			respError: "field Alias is reserved",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Alias with slash",
+			url:       "https://google.com",
+			alias:     "a/b",
+			respError: "field Alias contains forbidden characters",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Alias too long",
+			url:       "https://google.com",
+			alias:     strings.Repeat("a", 65),
+			respError: "field Alias has invalid length",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Expiration in the past",
+			url:       "https://google.com",
+			alias:     "campaign",
+			expiresAt: ptr(time.Now().Add(-time.Hour)),
+			respError: "field ExpiresAt must be in the future",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Zero click limit",
+			url:       "https://google.com",
+			alias:     "campaign",
+			maxClicks: ptr(int64(0)),
+			respError: "field MaxClicks must be greater than 0",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Scheduled launch",
+			url:       "https://google.com",
+			alias:     "launch",
+			notBefore: ptr(time.Now().Add(time.Hour)),
+			expiresAt: ptr(time.Now().Add(48 * time.Hour)),
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "Expiration before launch",
+			url:       "https://google.com",
+			alias:     "launch",
+			notBefore: ptr(time.Now().Add(48 * time.Hour)),
+			expiresAt: ptr(time.Now().Add(time.Hour)),
+			respError: "field ExpiresAt must be after NotBefore",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Private target",
+			url:       "http://127.0.0.1/admin",
+			alias:     "internal",
+			blocked:   urlpolicy.ErrDeniedAddress,
+			respError: "URL points to a private address",
+			wantCode:  http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range cases {
@@ -84,22 +264,62 @@ func TestSaveHandler(t *testing.T) {
 
 			// мок настраиваться только если:
 			// ожидается успешный ответ или задана ошибка для мока
-			if tc.respError == "" || tc.mockError != nil {
+			if (tc.respError == "" || tc.mockError != nil) && tc.blocked == nil {
 				// мок ожидать вызова SaveURL с аргументами tc.url и любым string
-				urlSaverMock.On("SaveURL", tc.url, mock.AnythingOfType("string")).
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link models.Link) bool {
+					if tc.password != "" && bcrypt.CompareHashAndPassword(link.PasswordHash, []byte(tc.password)) != nil {
+						return false
+					}
+					return link.URL == tc.url && link.Alias != "" && link.UserID == userID && link.RedirectCode == tc.redirect &&
+						(tc.notBefore == nil || link.NotBefore.Equal(*tc.notBefore))
+				})).
 					Return(int64(1), tc.mockError). // возвращает 1 и ошибку
 					Once()                          // метод вызывается только один раз
 			}
 
-			producerProvider := kafka.NewProducer([]string{"kafka:9092"}, "link-events")
+			// повторное сокращение возвращает уже существующую ссылку
+			if tc.existing != "" {
+				stored := tc.stored
+				stored.Alias, stored.URL, stored.UserID = tc.existing, tc.url, userID
+
+				urlSaverMock.On("UserLinkByURL", int64(userID), "", tc.url).
+					Return(stored, nil).
+					Once()
+			}
+
+			producerMock := mocks.NewProducerProvider(t)
+
+			checkerMock := policymocks.NewChecker(t)
+			checkerMock.On("Check", mock.Anything, tc.url).Return(tc.blocked).Maybe()
+
+			// отклонённая ссылка попадает в аудит
+			if tc.blocked != nil {
+				producerMock.On("Publish", mock.Anything, "42", mock.MatchedBy(func(ev map[string]interface{}) bool {
+					return ev["type"] == kafka.EventLinkBlocked && ev["action"] == "create" && ev["url"] == tc.url
+				})).
+					Return(nil).
+					Once()
+			}
+
+			// событие публикуется только после успешного сохранения
+			if tc.respError == "" && tc.mockError == nil {
+				producerMock.On("Publish", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+					Return(nil).
+					Once()
+			}
 
 			// создание хендлера: принимает заглушку и мок
-			handler := New(slogdiscard.NewDiscardLogger(), urlSaverMock, producerProvider)
+			handler := New(slogdiscard.NewDiscardLogger(), urlSaverMock, producerMock, newAliasValidator(t), alias.Random{Length: 6}, checkerMock, noTemplates(t), mocks.NewDomainLookup(t))
 
 			// тело запроса в JSON
-			bodyBytes, err := json.Marshal(map[string]string{
-				"url":   tc.url,
-				"alias": tc.alias,
+			bodyBytes, err := json.Marshal(Request{
+				URL:          tc.url,
+				Alias:        tc.alias,
+				ExpiresAt:    tc.expiresAt,
+				NotBefore:    tc.notBefore,
+				MaxClicks:    tc.maxClicks,
+				Password:     tc.password,
+				RedirectCode: tc.redirect,
 			})
 			require.NoError(t, err)
 
@@ -107,6 +327,9 @@ func TestSaveHandler(t *testing.T) {
 			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(bodyBytes))
 			require.NoError(t, err)
 
+			// имитация авторизованного пользователя
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
 			// Запись ответа:
 			// 1. запись ответа сервера
 			rr := httptest.NewRecorder()
@@ -122,6 +345,297 @@ func TestSaveHandler(t *testing.T) {
 
 			require.NoError(t, json.Unmarshal([]byte(body), &resp))
 			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.existing != "" {
+				var got ExistingResponse
+				require.NoError(t, json.Unmarshal([]byte(body), &got))
+				require.True(t, got.Existing)
+				require.Equal(t, tc.existing, got.Alias)
+			}
+		})
+	}
+}
+
+func TestSaveHandlerAliasRetry(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	producerMock := mocks.NewProducerProvider(t)
+	generatorMock := mocks.NewAliasGenerator(t)
+
+	// the reserved word is made again before it reaches storage
+	generated := []string{"login", "taken", "free"}
+	generatorMock.On("Generate", mock.AnythingOfType("*models.Link")).
+		Run(func(args mock.Arguments) {
+			link := args.Get(0).(*models.Link)
+			link.Alias, generated = generated[0], generated[1:]
+		}).
+		Return(nil).
+		Times(3)
+
+	urlSaverMock.On("SaveURL", mock.MatchedBy(func(link models.Link) bool { return link.Alias == "taken" })).
+		Return(int64(0), storage.ErrAliasExists).
+		Once()
+	urlSaverMock.On("SaveURL", mock.MatchedBy(func(link models.Link) bool { return link.Alias == "free" })).
+		Return(int64(2), nil).
+		Once()
+	producerMock.On("Publish", mock.Anything, mock.AnythingOfType("string"), mock.Anything).Return(nil).Once()
+
+	checkerMock := policymocks.NewChecker(t)
+	checkerMock.On("Check", mock.Anything, "https://google.com").Return(nil).Once()
+
+	handler := New(slogdiscard.NewDiscardLogger(), urlSaverMock, producerMock, newAliasValidator(t), generatorMock, checkerMock, noTemplates(t), mocks.NewDomainLookup(t))
+
+	req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(`{"url":"https://google.com"}`))
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), `"free"`)
+}
+
+func TestSaveHandlerDomain(t *testing.T) {
+	cases := []struct {
+		name        string
+		domain      models.Domain
+		domainError error
+		respError   string
+		wantCode    int
+	}{
+		{
+			name:     "Verified by the user",
+			domain:   models.Domain{ID: 1, UserID: userID, Host: "go.acme.com"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Verified by another user",
+			domain:    models.Domain{ID: 2, UserID: userID + 1, Host: "go.acme.com"},
+			respError: "domain is not verified",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:        "Not verified",
+			domainError: storage.ErrDomainNotFound,
+			respError:   "domain is not verified",
+			wantCode:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			producerMock := mocks.NewProducerProvider(t)
+			checkerMock := policymocks.NewChecker(t)
+			domainsMock := mocks.NewDomainLookup(t)
+
+			domainsMock.On("Domain", "go.acme.com").Return(tc.domain, tc.domainError).Once()
+
+			if tc.respError == "" {
+				checkerMock.On("Check", mock.Anything, "https://acme.com").Return(nil).Once()
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link models.Link) bool {
+					return link.Domain == "go.acme.com" && link.Alias == "promo"
+				})).
+					Return(int64(1), nil).
+					Once()
+				producerMock.On("Publish", mock.Anything, "42", mock.MatchedBy(func(ev map[string]interface{}) bool {
+					return ev["alias"] == "go.acme.com/promo" && ev["domain"] == "go.acme.com"
+				})).
+					Return(nil).
+					Once()
+			}
+
+			handler := New(slogdiscard.NewDiscardLogger(), urlSaverMock, producerMock, newAliasValidator(t), alias.Random{Length: 6}, checkerMock, noTemplates(t), domainsMock)
+
+			body := `{"url":"https://acme.com","alias":"promo","domain":"Go.Acme.com"}`
+			req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
 		})
 	}
 }
+
+func TestSaveHandlerTagsAndFolder(t *testing.T) {
+	cases := []struct {
+		name      string
+		mockError error
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Success",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Folder of another user",
+			mockError: storage.ErrFolderNotFound,
+			respError: "folder not found",
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("SaveURL", mock.MatchedBy(func(link models.Link) bool {
+				// теги очищаются от пробелов и дублей
+				return link.FolderID != nil && *link.FolderID == 5 &&
+					len(link.Tags) == 2 && link.Tags[0] == "promo" && link.Tags[1] == "spring"
+			})).
+				Return(int64(1), tc.mockError).
+				Once()
+
+			producerMock := mocks.NewProducerProvider(t)
+			if tc.mockError == nil {
+				producerMock.On("Publish", mock.Anything, "42", mock.MatchedBy(func(ev map[string]interface{}) bool {
+					return len(ev["tags"].([]string)) == 2
+				})).
+					Return(nil).
+					Once()
+			}
+
+			checkerMock := policymocks.NewChecker(t)
+			checkerMock.On("Check", mock.Anything, "https://google.com").Return(nil).Once()
+
+			handler := New(slogdiscard.NewDiscardLogger(), urlSaverMock, producerMock, newAliasValidator(t), alias.Random{Length: 6}, checkerMock, noTemplates(t), mocks.NewDomainLookup(t))
+
+			body := `{"url":"https://google.com","alias":"spring","tags":["promo"," spring","promo"],"folder_id":5}`
+			req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+func TestSaveHandlerUTM(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		templateID int64
+		template   models.UTMTemplate
+		mockError  error
+		wantURL    string
+		respError  string
+		wantCode   int
+	}{
+		{
+			name:     "Parameters from request",
+			body:     `{"url":"https://example.com/?utm_source=old&page=2","alias":"sale","utm":{"utm_source":"twitter","utm_campaign":"spring"}}`,
+			wantURL:  "https://example.com/?page=2&utm_campaign=spring&utm_source=twitter",
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Default template fills missing parameters",
+			body: `{"url":"https://example.com/","alias":"sale","utm":{"utm_campaign":"spring"}}`,
+			template: models.UTMTemplate{ID: 1, Default: true, UTM: models.UTM{
+				Source: "newsletter", Medium: "email", Campaign: "weekly",
+			}},
+			wantURL:  "https://example.com/?utm_campaign=spring&utm_medium=email&utm_source=newsletter",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "Chosen template",
+			body:       `{"url":"https://example.com/","alias":"sale","utm_template_id":3}`,
+			templateID: 3,
+			template:   models.UTMTemplate{ID: 3, UTM: models.UTM{Source: "partner"}},
+			wantURL:    "https://example.com/?utm_source=partner",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "Unknown template",
+			body:       `{"url":"https://example.com/","alias":"sale","utm_template_id":4}`,
+			templateID: 4,
+			mockError:  storage.ErrTemplateNotFound,
+			respError:  "template not found",
+			wantCode:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			templatesMock := mocks.NewUTMTemplates(t)
+			if tc.templateID != 0 {
+				templatesMock.On("UTMTemplate", int64(userID), tc.templateID).Return(tc.template, tc.mockError).Once()
+			} else {
+				templatesMock.On("DefaultUTMTemplate", int64(userID)).Return(tc.template, nil).Once()
+			}
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			producerMock := mocks.NewProducerProvider(t)
+			checkerMock := policymocks.NewChecker(t)
+
+			if tc.respError == "" {
+				checkerMock.On("Check", mock.Anything, tc.wantURL).Return(nil).Once()
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link models.Link) bool { return link.URL == tc.wantURL })).
+					Return(int64(1), nil).
+					Once()
+				producerMock.On("Publish", mock.Anything, "42", mock.MatchedBy(func(ev map[string]interface{}) bool {
+					return ev["url"] == tc.wantURL && ev["utm_source"] != ""
+				})).
+					Return(nil).
+					Once()
+			}
+
+			handler := New(slogdiscard.NewDiscardLogger(), urlSaverMock, producerMock, newAliasValidator(t), alias.Random{Length: 6}, checkerMock, templatesMock, mocks.NewDomainLookup(t))
+
+			req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+// noTemplates is a user without campaign templates
+func noTemplates(t *testing.T) *mocks.UTMTemplates {
+	t.Helper()
+
+	templatesMock := mocks.NewUTMTemplates(t)
+	templatesMock.On("DefaultUTMTemplate", mock.Anything).Return(models.UTMTemplate{}, storage.ErrTemplateNotFound).Maybe()
+
+	return templatesMock
+}
+
+func newAliasValidator(t *testing.T) *alias.Validator {
+	t.Helper()
+
+	v, err := alias.NewValidator(alias.Options{MinLength: 2, MaxLength: 64, Reserved: []string{"login", "url"}})
+	require.NoError(t, err)
+
+	return v
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
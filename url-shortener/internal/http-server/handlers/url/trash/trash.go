@@ -0,0 +1,158 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+// Item is a link in the trash together with the time it will be purged at
+type Item struct {
+	models.Link
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+type Response struct {
+	resp.Response
+	Links []Item `json:"links"`
+}
+
+//go:generate mockery --name=TrashLister --dir=. --output=./mocks --filename=trash_lister_mock.go --outpkg=mocks
+type TrashLister interface {
+	TrashedURLs(userID int64) ([]models.Link, error)
+}
+
+//go:generate mockery --name=URLRestorer --dir=. --output=./mocks --filename=url_restorer_mock.go --outpkg=mocks
+type URLRestorer interface {
+	TrashedURLOwner(alias string) (int64, error)
+	RestoreURL(alias string) error
+}
+
+//go:generate mockery --name=ProducerProvider --dir=. --output=./mocks --filename=producer_provider_mock.go --outpkg=mocks
+type ProducerProvider interface {
+	Publish(ctx context.Context, key string, value interface{}) error
+}
+
+// List returns deleted links of the user that can still be restored
+func List(log *slog.Logger, lister TrashLister, retention time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.trash.List"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		links, err := lister.TrashedURLs(int64(userID))
+		if err != nil {
+			log.Error("failed to list trash", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to list trash"))
+			return
+		}
+
+		items := make([]Item, 0, len(links))
+		for _, link := range links {
+			item := Item{Link: link}
+			if link.DeletedAt != nil {
+				item.PurgeAt = link.DeletedAt.Add(retention)
+			}
+			items = append(items, item)
+		}
+
+		resp.NewJSON(w, r, http.StatusOK, Response{
+			Response: resp.OK(),
+			Links:    items,
+		})
+	}
+}
+
+// Restore takes the alias out of the trash and makes it redirect again
+func Restore(log *slog.Logger, restorer URLRestorer, adminChecker access.AdminChecker, producer ProducerProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.trash.Restore"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("alias is empty"))
+			return
+		}
+
+		ownerID, err := restorer.TrashedURLOwner(alias)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Info("alias not found in trash", slog.String("alias", alias))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found in trash"))
+			return
+		case err != nil:
+			log.Error("failed to get alias owner", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+			return
+		}
+
+		if !access.Allow(w, r, log, adminChecker, ownerID, userID, alias) {
+			return
+		}
+
+		err = restorer.RestoreURL(alias)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Info("alias not found in trash", slog.String("alias", alias))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found in trash"))
+			return
+		case errors.Is(err, storage.ErrURLExists):
+			log.Info("URL already exists", slog.String("alias", alias))
+			resp.NewJSON(w, r, http.StatusConflict, resp.Error("URL already exists"))
+			return
+		case err != nil:
+			log.Error("failed to restore url", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to restore URL"))
+			return
+		}
+
+		log.Info("url restored", slog.String("alias", alias))
+
+		ev := map[string]interface{}{
+			"type":      kafka.EventLinkRestored,
+			"timestamp": time.Now().UTC(),
+			"user_id":   int64(userID),
+			"alias":     alias,
+		}
+
+		if err := producer.Publish(r.Context(), strconv.FormatInt(ownerID, 10), ev); err != nil {
+			log.Error("failed to send message to Kafka", sl.Err(err))
+		}
+
+		resp.RespOk(w, r, alias)
+	}
+}
@@ -0,0 +1,129 @@
+package trash
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/trash/mocks"
+	accessmocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access/mocks"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 6
+
+func TestListHandler(t *testing.T) {
+	deletedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	listerMock := mocks.NewTrashLister(t)
+	listerMock.On("TrashedURLs", int64(userID)).
+		Return([]models.Link{{ID: 1, Alias: "old", URL: "https://example.com", UserID: userID, DeletedAt: &deletedAt}}, nil).
+		Once()
+
+	r := chi.NewRouter()
+	r.Get("/url/trash", List(slogdiscard.NewDiscardLogger(), listerMock, 72*time.Hour))
+
+	req := httptest.NewRequest(http.MethodGet, "/url/trash", nil)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var got Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Len(t, got.Links, 1)
+	require.Equal(t, "old", got.Links[0].Alias)
+	require.True(t, deletedAt.Add(72*time.Hour).Equal(got.Links[0].PurgeAt))
+}
+
+func TestRestoreHandler(t *testing.T) {
+	cases := []struct {
+		name         string
+		ownerID      int64
+		ownerError   error
+		restoreError error
+		respError    string
+		wantCode     int
+	}{
+		{
+			name:     "Success",
+			ownerID:  userID,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "Not in trash",
+			ownerError: storage.ErrAliasNotFound,
+			respError:  "alias not found in trash",
+			wantCode:   http.StatusNotFound,
+		},
+		{
+			name:      "Someone else's link",
+			ownerID:   userID + 1,
+			respError: "forbidden",
+			wantCode:  http.StatusForbidden,
+		},
+		{
+			name:         "Target shortened again",
+			ownerID:      userID,
+			restoreError: storage.ErrURLExists,
+			respError:    "URL already exists",
+			wantCode:     http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			restorerMock := mocks.NewURLRestorer(t)
+			adminMock := accessmocks.NewAdminChecker(t)
+			producerMock := mocks.NewProducerProvider(t)
+
+			restorerMock.On("TrashedURLOwner", "old").Return(tc.ownerID, tc.ownerError).Once()
+
+			if tc.ownerID != 0 && tc.ownerID != userID {
+				adminMock.On("IsAdmin", mock.Anything, int64(userID)).Return(false, nil).Once()
+			}
+
+			if tc.ownerID == userID {
+				restorerMock.On("RestoreURL", "old").Return(tc.restoreError).Once()
+			}
+
+			if tc.wantCode == http.StatusOK {
+				producerMock.On("Publish", mock.Anything, "6", mock.MatchedBy(func(ev map[string]interface{}) bool {
+					return ev["type"] == kafka.EventLinkRestored && ev["alias"] == "old"
+				})).
+					Return(nil).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/restore", Restore(slogdiscard.NewDiscardLogger(), restorerMock, adminMock, producerMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/url/old/restore", nil)
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
@@ -0,0 +1,686 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/deeplink"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+type Request struct {
+	URL string `json:"url" validate:"required,url"`
+}
+
+type TagsRequest struct {
+	Tags []string `json:"tags" validate:"max=20,dive,max=50"`
+}
+
+// FolderRequest moves the link, a null folder_id takes it out of its folder
+type FolderRequest struct {
+	FolderID *int64 `json:"folder_id" validate:"omitempty,gt=0"`
+}
+
+// RedirectRequest sets the status code of redirects, 0 or null restores the default
+type RedirectRequest struct {
+	RedirectCode int `json:"redirect_code" validate:"omitempty,oneof=301 302 307 308"`
+}
+
+// ForwardingRequest turns query and path passthrough of the link on and off
+type ForwardingRequest struct {
+	ForwardQuery bool `json:"forward_query"`
+	ForwardPath  bool `json:"forward_path"`
+}
+
+// PreviewRequest sets what the preview page shows, AlwaysPreview shows it on every visit
+type PreviewRequest struct {
+	Title         string `json:"title" validate:"max=200"`
+	Description   string `json:"description" validate:"max=1000"`
+	AlwaysPreview bool   `json:"always_preview"`
+}
+
+// DeepLinkRequest sets the app and store targets of the link, a null deep_link turns it off
+type DeepLinkRequest struct {
+	DeepLink *models.DeepLink `json:"deep_link"`
+}
+
+type RevisionsResponse struct {
+	resp.Response
+	Revisions []models.Revision `json:"revisions"`
+}
+
+//go:generate mockery --name=URLUpdater --dir=. --output=./mocks --filename=url_updater_mock.go --outpkg=mocks
+type URLUpdater interface {
+	URLOwner(alias string) (int64, error)
+	GetLink(alias string) (models.Link, error)
+	UpdateURL(alias string, newURL string, changedBy int64) (int64, string, error)
+	URLRevisions(alias string) ([]models.Revision, error)
+	URLRevision(alias string, revisionID int64) (models.Revision, error)
+}
+
+//go:generate mockery --name=ProducerProvider --dir=. --output=./mocks --filename=producer_provider_mock.go --outpkg=mocks
+type ProducerProvider interface {
+	Publish(ctx context.Context, key string, value interface{}) error
+	Close() error
+}
+
+// LinkLabeler changes tags and folder of links
+//
+//go:generate mockery --name=LinkLabeler --dir=. --output=./mocks --filename=link_labeler_mock.go --outpkg=mocks
+type LinkLabeler interface {
+	SetLinkTags(alias string, tags []string) error
+	SetLinkFolder(alias string, folderID *int64) error
+}
+
+// RedirectSetter changes the status code of link redirects
+//
+//go:generate mockery --name=RedirectSetter --dir=. --output=./mocks --filename=redirect_setter_mock.go --outpkg=mocks
+type RedirectSetter interface {
+	SetRedirectCode(alias string, code int) error
+}
+
+// ForwardingSetter changes what the visit passes on to the target
+//
+//go:generate mockery --name=ForwardingSetter --dir=. --output=./mocks --filename=forwarding_setter_mock.go --outpkg=mocks
+type ForwardingSetter interface {
+	SetForwarding(alias string, query, path bool) error
+}
+
+// PreviewSetter changes the preview page of links
+//
+//go:generate mockery --name=PreviewSetter --dir=. --output=./mocks --filename=preview_setter_mock.go --outpkg=mocks
+type PreviewSetter interface {
+	SetPreview(alias, title, description string, always bool) error
+}
+
+// DeepLinkSetter changes app targets of links
+//
+//go:generate mockery --name=DeepLinkSetter --dir=. --output=./mocks --filename=deep_link_setter_mock.go --outpkg=mocks
+type DeepLinkSetter interface {
+	SetDeepLink(alias string, deepLink *models.DeepLink) error
+}
+
+// Pauser temporarily turns links off and on
+//
+//go:generate mockery --name=Pauser --dir=. --output=./mocks --filename=pauser_mock.go --outpkg=mocks
+type Pauser interface {
+	SetPaused(alias string, paused bool) (int64, bool, error)
+}
+
+// New changes the target of an existing alias
+func New(
+	log *slog.Logger,
+	updater URLUpdater,
+	adminChecker access.AdminChecker,
+	producer ProducerProvider,
+	checker urlpolicy.Checker,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.New"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, userID, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request body"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Error("invalid request", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		apply(w, r, log, updater, producer, checker, alias, req.URL, userID)
+	}
+}
+
+// Revisions lists previous targets of the alias
+func Revisions(log *slog.Logger, updater URLUpdater, adminChecker access.AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.Revisions"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		revisions, err := updater.URLRevisions(alias)
+		if err != nil {
+			log.Error("failed to get revisions", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+			return
+		}
+
+		resp.NewJSON(w, r, http.StatusOK, RevisionsResponse{
+			Response:  resp.OK(),
+			Revisions: revisions,
+		})
+	}
+}
+
+// Restore points the alias back to the target stored in the revision
+func Restore(
+	log *slog.Logger,
+	updater URLUpdater,
+	adminChecker access.AdminChecker,
+	producer ProducerProvider,
+	checker urlpolicy.Checker,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.Restore"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		revisionID, err := strconv.ParseInt(chi.URLParam(r, "revision"), 10, 64)
+		if err != nil {
+			log.Error("invalid revision id", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid revision id"))
+			return
+		}
+
+		alias, userID, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		rev, err := updater.URLRevision(alias, revisionID)
+		switch {
+		case errors.Is(err, storage.ErrRevisionNotFound):
+			log.Error("revision not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("revision not found"))
+			return
+		case err != nil:
+			log.Error("failed to get revision", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+			return
+		}
+
+		apply(w, r, log, updater, producer, checker, alias, rev.URL, userID)
+	}
+}
+
+// Tags replaces tags of the alias, unknown tags are created
+func Tags(log *slog.Logger, updater URLUpdater, adminChecker access.AdminChecker, labeler LinkLabeler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.Tags"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		var req TagsRequest
+		if !decode(w, r, log, &req) {
+			return
+		}
+
+		tags := models.NormalizeTags(req.Tags)
+
+		err := labeler.SetLinkTags(alias, tags)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to set tags", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to set tags"))
+			return
+		}
+
+		log.Info("tags set", slog.String("alias", alias), slog.Any("tags", tags))
+		resp.RespOk(w, r, alias)
+	}
+}
+
+// Folder moves the alias to another folder of its owner
+func Folder(log *slog.Logger, updater URLUpdater, adminChecker access.AdminChecker, labeler LinkLabeler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.Folder"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		var req FolderRequest
+		if !decode(w, r, log, &req) {
+			return
+		}
+
+		err := labeler.SetLinkFolder(alias, req.FolderID)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case errors.Is(err, storage.ErrFolderNotFound):
+			log.Error("folder not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("folder not found"))
+			return
+		case err != nil:
+			log.Error("failed to set folder", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to set folder"))
+			return
+		}
+
+		log.Info("folder set", slog.String("alias", alias))
+		resp.RespOk(w, r, alias)
+	}
+}
+
+// Redirect changes the status code the alias redirects with.
+// Browsers keep 301 and 308 redirects for redirect.max_age without asking the service,
+// until then they do not notice a pause, a deletion, a preview or a new target of the link
+func Redirect(log *slog.Logger, updater URLUpdater, adminChecker access.AdminChecker, setter RedirectSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.Redirect"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		var req RedirectRequest
+		if !decode(w, r, log, &req) {
+			return
+		}
+
+		err := setter.SetRedirectCode(alias, req.RedirectCode)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to set redirect code", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to set redirect code"))
+			return
+		}
+
+		log.Info("redirect code set", slog.String("alias", alias), slog.Int("code", req.RedirectCode))
+		resp.RespOk(w, r, alias)
+	}
+}
+
+// Forwarding sets whether the alias passes the query and the path after it on to the target
+func Forwarding(log *slog.Logger, updater URLUpdater, adminChecker access.AdminChecker, setter ForwardingSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.Forwarding"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		var req ForwardingRequest
+		if !decode(w, r, log, &req) {
+			return
+		}
+
+		err := setter.SetForwarding(alias, req.ForwardQuery, req.ForwardPath)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to set forwarding", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to set forwarding"))
+			return
+		}
+
+		log.Info("forwarding set",
+			slog.String("alias", alias),
+			slog.Bool("query", req.ForwardQuery),
+			slog.Bool("path", req.ForwardPath),
+		)
+		resp.RespOk(w, r, alias)
+	}
+}
+
+// Preview sets the title and description of the alias preview page and whether every visit gets it
+func Preview(log *slog.Logger, updater URLUpdater, adminChecker access.AdminChecker, setter PreviewSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.Preview"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		var req PreviewRequest
+		if !decode(w, r, log, &req) {
+			return
+		}
+
+		err := setter.SetPreview(alias, strings.TrimSpace(req.Title), strings.TrimSpace(req.Description), req.AlwaysPreview)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to set preview", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to set preview"))
+			return
+		}
+
+		log.Info("preview set", slog.String("alias", alias), slog.Bool("always", req.AlwaysPreview))
+		resp.RespOk(w, r, alias)
+	}
+}
+
+// DeepLink sets the app URI and store fallbacks of the alias
+func DeepLink(
+	log *slog.Logger,
+	updater URLUpdater,
+	adminChecker access.AdminChecker,
+	setter DeepLinkSetter,
+	checker urlpolicy.Checker,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.DeepLink"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		var req DeepLinkRequest
+		if !decode(w, r, log, &req) {
+			return
+		}
+
+		if req.DeepLink != nil {
+			if err := deeplink.Check(r.Context(), checker, *req.DeepLink); err != nil {
+				log.Warn("deep link rejected", sl.Err(err))
+				resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+				return
+			}
+		}
+
+		err := setter.SetDeepLink(alias, req.DeepLink)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to set deep link", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to set deep link"))
+			return
+		}
+
+		log.Info("deep link set", slog.String("alias", alias), slog.Bool("enabled", req.DeepLink != nil))
+		resp.RespOk(w, r, alias)
+	}
+}
+
+// Pause stops redirects of the alias until it is resumed and publishes link.paused event
+func Pause(
+	log *slog.Logger,
+	updater URLUpdater,
+	adminChecker access.AdminChecker,
+	pauser Pauser,
+	producer ProducerProvider,
+) http.HandlerFunc {
+	return setPaused(log, updater, adminChecker, pauser, producer, true)
+}
+
+// Resume lets the paused alias redirect again and publishes link.resumed event
+func Resume(
+	log *slog.Logger,
+	updater URLUpdater,
+	adminChecker access.AdminChecker,
+	pauser Pauser,
+	producer ProducerProvider,
+) http.HandlerFunc {
+	return setPaused(log, updater, adminChecker, pauser, producer, false)
+}
+
+func setPaused(
+	log *slog.Logger,
+	updater URLUpdater,
+	adminChecker access.AdminChecker,
+	pauser Pauser,
+	producer ProducerProvider,
+	paused bool,
+) http.HandlerFunc {
+	evType := kafka.EventLinkResumed
+	if paused {
+		evType = kafka.EventLinkPaused
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.setPaused"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, userID, ok := access.Authorize(w, r, log, updater, adminChecker)
+		if !ok {
+			return
+		}
+
+		link, ok := loadLink(w, r, log, updater, alias)
+		if !ok {
+			return
+		}
+
+		id, changed, err := pauser.SetPaused(alias, paused)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to set paused", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to change link state"))
+			return
+		}
+
+		// repeated calls are fine, only the transition is an event
+		if changed {
+			link.ID = id
+			ev := linkEvent(evType, link, alias, userID)
+
+			if err := producer.Publish(context.Background(), strconv.FormatInt(link.UserID, 10), ev); err != nil {
+				log.Error("failed to send message to Kafka", sl.Err(err))
+			}
+		}
+
+		log.Info("link state set", slog.String("alias", alias), slog.Bool("paused", paused), slog.Bool("changed", changed))
+		resp.RespOk(w, r, alias)
+	}
+}
+
+// decode reads and validates the JSON body into req
+// and writes the error response when it is invalid
+func decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request body"))
+		return false
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+
+		log.Error("invalid request", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+		return false
+	}
+
+	return true
+}
+
+// apply checks and stores the new target and publishes link.updated event
+func apply(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	updater URLUpdater,
+	producer ProducerProvider,
+	checker urlpolicy.Checker,
+	alias string,
+	newURL string,
+	userID int,
+) {
+	// restored revisions are checked as well, the policy may have changed since
+	if err := checker.Check(r.Context(), newURL); err != nil {
+		log.Warn("target URL rejected", slog.String("url", newURL), sl.Err(err))
+
+		ev := urlpolicy.Event(r, int64(userID), newURL, err, "update")
+		if err := producer.Publish(context.Background(), strconv.FormatInt(int64(userID), 10), ev); err != nil {
+			log.Error("failed to send message to Kafka", sl.Err(err))
+		}
+
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+		return
+	}
+
+	link, ok := loadLink(w, r, log, updater, alias)
+	if !ok {
+		return
+	}
+
+	id, oldURL, err := updater.UpdateURL(alias, newURL, int64(userID))
+	switch {
+	case errors.Is(err, storage.ErrAliasNotFound):
+		log.Error("alias not found", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+		return
+	case errors.Is(err, storage.ErrURLExists):
+		log.Error("URL already exists", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusConflict, resp.Error("URL already exists"))
+		return
+	case err != nil:
+		log.Error("failed to update url", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to update URL"))
+		return
+	}
+
+	link.ID = id
+	ev := linkEvent(kafka.EventLinkUpdated, link, alias, userID)
+	ev["url"] = newURL
+	ev["old_url"] = oldURL
+	// analytics stores UTM parameters of the new target in their own columns
+	for key, value := range models.ParseUTM(newURL).Fields() {
+		ev[key] = value
+	}
+
+	if err := producer.Publish(context.Background(), strconv.FormatInt(link.UserID, 10), ev); err != nil {
+		log.Error("failed to send message to Kafka", sl.Err(err))
+	}
+
+	log.Info("url updated", slog.Int64("id", id))
+	resp.RespOk(w, r, alias)
+}
+
+// loadLink returns the link of the alias for the events about it
+// and writes the error response when it cannot be loaded
+func loadLink(w http.ResponseWriter, r *http.Request, log *slog.Logger, updater URLUpdater, alias string) (models.Link, bool) {
+	link, err := updater.GetLink(alias)
+	switch {
+	case errors.Is(err, storage.ErrURLNotFound):
+		log.Error("alias not found", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+		return models.Link{}, false
+	case err != nil:
+		log.Error("failed to get link", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+		return models.Link{}, false
+	}
+
+	return link, true
+}
+
+// linkEvent starts an event about the link. It belongs to the owner of the link and carries
+// its tags, actor_id is the user who made the change, an admin may change links of others
+func linkEvent(evType string, link models.Link, alias string, actorID int) map[string]interface{} {
+	return map[string]interface{}{
+		"type":      evType,
+		"timestamp": time.Now().UTC(),
+		"user_id":   link.UserID,
+		"actor_id":  int64(actorID),
+		"alias":     alias,
+		"link_id":   link.ID,
+		"tags":      link.Tags,
+	}
+}
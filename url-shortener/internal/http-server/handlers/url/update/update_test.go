@@ -0,0 +1,560 @@
+package update
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/update/mocks"
+	accessmocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access/mocks"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	policymocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 5
+
+func TestUpdateHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		url         string
+		ownerID     int64
+		checkAdmin  bool
+		admin       bool
+		updateError error
+		updated     bool
+		blocked     error
+		respError   string
+		wantCode    int
+	}{
+		{
+			name:     "Success",
+			url:      "https://example.com/new",
+			ownerID:  userID,
+			updated:  true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "UTM parameters",
+			url:      "https://example.com/new?utm_source=mail&utm_campaign=spring",
+			ownerID:  userID,
+			updated:  true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "Admin changes a link of another user",
+			url:        "https://example.com/new",
+			ownerID:    userID + 1,
+			checkAdmin: true,
+			admin:      true,
+			updated:    true,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:      "Invalid URL",
+			url:       "not a url",
+			ownerID:   userID,
+			respError: "field URL is not a valid URL",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:       "Not owner",
+			url:        "https://example.com/new",
+			ownerID:    userID + 1,
+			checkAdmin: true,
+			respError:  "forbidden",
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:        "URL already exists",
+			url:         "https://example.com/taken",
+			ownerID:     userID,
+			updateError: storage.ErrURLExists,
+			respError:   "URL already exists",
+			wantCode:    http.StatusConflict,
+		},
+		{
+			name:      "Blocked target",
+			url:       "https://phishing.example/login",
+			ownerID:   userID,
+			blocked:   urlpolicy.ErrBlocked,
+			respError: "URL domain is blocked",
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			updaterMock := mocks.NewURLUpdater(t)
+			adminMock := accessmocks.NewAdminChecker(t)
+			producerMock := mocks.NewProducerProvider(t)
+
+			updaterMock.On("URLOwner", "promo").Return(tc.ownerID, nil).Once()
+
+			if tc.checkAdmin {
+				adminMock.On("IsAdmin", mock.Anything, int64(userID)).Return(tc.admin, nil).Once()
+			}
+
+			if tc.updated || tc.updateError != nil {
+				updaterMock.On("GetLink", "promo").
+					Return(models.Link{ID: 1, Alias: "promo", UserID: tc.ownerID, Tags: []string{"spring"}}, nil).
+					Once()
+				updaterMock.On("UpdateURL", "promo", tc.url, int64(userID)).
+					Return(int64(1), "https://example.com/old", tc.updateError).
+					Once()
+			}
+
+			if tc.updated {
+				// the event belongs to the owner, the admin is only the actor
+				owner := strconv.FormatInt(tc.ownerID, 10)
+				producerMock.On("Publish", mock.Anything, owner, mock.MatchedBy(func(ev map[string]interface{}) bool {
+					if ev["user_id"] != tc.ownerID || ev["actor_id"] != int64(userID) || !slices.Equal(ev["tags"].([]string), []string{"spring"}) {
+						return false
+					}
+					for key, value := range models.ParseUTM(tc.url).Fields() {
+						if ev[key] != value {
+							return false
+						}
+					}
+					return ev["old_url"] == "https://example.com/old" && ev["url"] == tc.url
+				})).Return(nil).Once()
+			}
+
+			checkerMock := policymocks.NewChecker(t)
+			checkerMock.On("Check", mock.Anything, tc.url).Return(tc.blocked).Maybe()
+
+			if tc.blocked != nil {
+				producerMock.On("Publish", mock.Anything, "5", mock.MatchedBy(func(ev map[string]interface{}) bool {
+					return ev["type"] == kafka.EventLinkBlocked && ev["action"] == "update"
+				})).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Patch("/url/{alias}", New(slogdiscard.NewDiscardLogger(), updaterMock, adminMock, producerMock, checkerMock))
+
+			body, err := json.Marshal(Request{URL: tc.url})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPatch, "/url/promo", bytes.NewReader(body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+func TestRestoreHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		revision  string
+		mockError error
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Success",
+			revision: "3",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Revision not found",
+			revision:  "4",
+			mockError: storage.ErrRevisionNotFound,
+			respError: "revision not found",
+			wantCode:  http.StatusNotFound,
+		},
+		{
+			name:      "Invalid revision",
+			revision:  "latest",
+			respError: "invalid revision id",
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			updaterMock := mocks.NewURLUpdater(t)
+			adminMock := accessmocks.NewAdminChecker(t)
+			producerMock := mocks.NewProducerProvider(t)
+
+			if tc.wantCode != http.StatusBadRequest {
+				updaterMock.On("URLOwner", "promo").Return(int64(userID), nil).Once()
+				updaterMock.On("URLRevision", "promo", mock.AnythingOfType("int64")).
+					Return(models.Revision{ID: 3, URL: "https://example.com/old"}, tc.mockError).
+					Once()
+			}
+
+			if tc.wantCode == http.StatusOK {
+				updaterMock.On("GetLink", "promo").Return(models.Link{ID: 1, Alias: "promo", UserID: userID}, nil).Once()
+				updaterMock.On("UpdateURL", "promo", "https://example.com/old", int64(userID)).
+					Return(int64(1), "https://example.com/new", nil).
+					Once()
+				producerMock.On("Publish", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+					Return(nil).
+					Once()
+			}
+
+			checkerMock := policymocks.NewChecker(t)
+			checkerMock.On("Check", mock.Anything, "https://example.com/old").Return(nil).Maybe()
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/revisions/{revision}/restore", Restore(slogdiscard.NewDiscardLogger(), updaterMock, adminMock, producerMock, checkerMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/url/promo/revisions/"+tc.revision+"/restore", nil)
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+func TestTagsHandler(t *testing.T) {
+	updaterMock := mocks.NewURLUpdater(t)
+	labelerMock := mocks.NewLinkLabeler(t)
+
+	updaterMock.On("URLOwner", "promo").Return(int64(userID), nil).Once()
+	labelerMock.On("SetLinkTags", "promo", []string{"sale", "spring"}).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Put("/url/{alias}/tags", Tags(slogdiscard.NewDiscardLogger(), updaterMock, accessmocks.NewAdminChecker(t), labelerMock))
+
+	body := bytes.NewBufferString(`{"tags":["sale","spring"," sale "]}`)
+	req := httptest.NewRequest(http.MethodPut, "/url/promo/tags", body)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestFolderHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		folderID  *int64
+		mockError error
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Move",
+			body:     `{"folder_id":3}`,
+			folderID: ptr(int64(3)),
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Remove from folder",
+			body:     `{"folder_id":null}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Folder of another user",
+			body:      `{"folder_id":9}`,
+			folderID:  ptr(int64(9)),
+			mockError: storage.ErrFolderNotFound,
+			respError: "folder not found",
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			updaterMock := mocks.NewURLUpdater(t)
+			labelerMock := mocks.NewLinkLabeler(t)
+
+			updaterMock.On("URLOwner", "promo").Return(int64(userID), nil).Once()
+			labelerMock.On("SetLinkFolder", "promo", tc.folderID).Return(tc.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Put("/url/{alias}/folder", Folder(slogdiscard.NewDiscardLogger(), updaterMock, accessmocks.NewAdminChecker(t), labelerMock))
+
+			req := httptest.NewRequest(http.MethodPut, "/url/promo/folder", bytes.NewBufferString(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRedirectHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		code      int
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Permanent",
+			body:     `{"redirect_code":308}`,
+			code:     http.StatusPermanentRedirect,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Back to default",
+			body:     `{"redirect_code":null}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Unsupported code",
+			body:      `{"redirect_code":303}`,
+			code:      -1,
+			respError: "field RedirectCode must be one of 301 302 307 308",
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			updaterMock := mocks.NewURLUpdater(t)
+			setterMock := mocks.NewRedirectSetter(t)
+
+			updaterMock.On("URLOwner", "promo").Return(int64(userID), nil).Once()
+			if tc.code >= 0 {
+				setterMock.On("SetRedirectCode", "promo", tc.code).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Put("/url/{alias}/redirect", Redirect(slogdiscard.NewDiscardLogger(), updaterMock, accessmocks.NewAdminChecker(t), setterMock))
+
+			req := httptest.NewRequest(http.MethodPut, "/url/promo/redirect", bytes.NewBufferString(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+func TestForwardingHandler(t *testing.T) {
+	updaterMock := mocks.NewURLUpdater(t)
+	setterMock := mocks.NewForwardingSetter(t)
+
+	updaterMock.On("URLOwner", "docs").Return(int64(userID), nil).Once()
+	setterMock.On("SetForwarding", "docs", false, true).Return(storage.ErrAliasNotFound).Once()
+
+	r := chi.NewRouter()
+	r.Put("/url/{alias}/forwarding", Forwarding(slogdiscard.NewDiscardLogger(), updaterMock, accessmocks.NewAdminChecker(t), setterMock))
+
+	req := httptest.NewRequest(http.MethodPut, "/url/docs/forwarding", bytes.NewBufferString(`{"forward_path":true}`))
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestPauseHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		path      string
+		paused    bool
+		changed   bool
+		wantEvent string
+	}{
+		{
+			name:      "Pause",
+			path:      "/url/launch/pause",
+			paused:    true,
+			changed:   true,
+			wantEvent: kafka.EventLinkPaused,
+		},
+		{
+			name:      "Resume",
+			path:      "/url/launch/resume",
+			changed:   true,
+			wantEvent: kafka.EventLinkResumed,
+		},
+		{
+			name:   "Already paused",
+			path:   "/url/launch/pause",
+			paused: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			updaterMock := mocks.NewURLUpdater(t)
+			pauserMock := mocks.NewPauser(t)
+			producerMock := mocks.NewProducerProvider(t)
+
+			updaterMock.On("URLOwner", "launch").Return(int64(userID), nil).Once()
+			updaterMock.On("GetLink", "launch").
+				Return(models.Link{ID: 9, Alias: "launch", UserID: userID, Tags: []string{"launch"}}, nil).
+				Once()
+			pauserMock.On("SetPaused", "launch", tc.paused).Return(int64(9), tc.changed, nil).Once()
+
+			// the event is only sent when the state changes
+			if tc.wantEvent != "" {
+				producerMock.On("Publish", mock.Anything, "5", mock.MatchedBy(func(ev map[string]interface{}) bool {
+					return ev["type"] == tc.wantEvent && ev["alias"] == "launch" && ev["link_id"] == int64(9) &&
+						slices.Equal(ev["tags"].([]string), []string{"launch"})
+				})).
+					Return(nil).
+					Once()
+			}
+
+			log := slogdiscard.NewDiscardLogger()
+			adminMock := accessmocks.NewAdminChecker(t)
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/pause", Pause(log, updaterMock, adminMock, pauserMock, producerMock))
+			r.Post("/url/{alias}/resume", Resume(log, updaterMock, adminMock, pauserMock, producerMock))
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+func TestPreviewHandler(t *testing.T) {
+	updaterMock := mocks.NewURLUpdater(t)
+	setterMock := mocks.NewPreviewSetter(t)
+
+	updaterMock.On("URLOwner", "docs").Return(int64(userID), nil).Once()
+	setterMock.On("SetPreview", "docs", "Docs", "Release notes", true).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Put("/url/{alias}/preview", Preview(slogdiscard.NewDiscardLogger(), updaterMock, accessmocks.NewAdminChecker(t), setterMock))
+
+	body := bytes.NewBufferString(`{"title":" Docs ","description":"Release notes","always_preview":true}`)
+	req := httptest.NewRequest(http.MethodPut, "/url/docs/preview", body)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestDeepLinkHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		blocked   error
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Success",
+			body:     `{"deep_link":{"app_uri":"myapp://promo","ios_store_url":"https://apps.apple.com/app/id1"}}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Turn off",
+			body:     `{"deep_link":null}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Script scheme",
+			body:      `{"deep_link":{"app_uri":"javascript:alert(1)"}}`,
+			respError: models.ErrAppURIScheme.Error(),
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Blocked store",
+			body:      `{"deep_link":{"app_uri":"myapp://promo","ios_store_url":"http://127.0.0.1/store"}}`,
+			blocked:   urlpolicy.ErrDeniedAddress,
+			respError: urlpolicy.ErrDeniedAddress.Error(),
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			updaterMock := mocks.NewURLUpdater(t)
+			setterMock := mocks.NewDeepLinkSetter(t)
+			checkerMock := policymocks.NewChecker(t)
+
+			updaterMock.On("URLOwner", "app").Return(int64(userID), nil).Once()
+			checkerMock.On("Check", mock.Anything, mock.AnythingOfType("string")).Return(tc.blocked).Maybe()
+
+			if tc.respError == "" {
+				setterMock.On("SetDeepLink", "app", mock.AnythingOfType("*models.DeepLink")).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Put("/url/{alias}/deep-link", DeepLink(slogdiscard.NewDiscardLogger(), updaterMock, accessmocks.NewAdminChecker(t), setterMock, checkerMock))
+
+			req := httptest.NewRequest(http.MethodPut, "/url/app/deep-link", bytes.NewBufferString(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package variants
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+// Request replaces all destinations of the split, an empty list turns it off
+type Request struct {
+	Variants []Variant `json:"variants" validate:"max=10,unique=Name,dive"`
+}
+
+type Variant struct {
+	// Name is stored in the visitor cookie and reported to analytics
+	Name   string `json:"name" validate:"required,alphanum,max=32"`
+	URL    string `json:"url" validate:"required,url"`
+	Weight int    `json:"weight" validate:"gt=0,lte=10000"`
+}
+
+type Response struct {
+	resp.Response
+	Variants []models.Variant `json:"variants"`
+}
+
+//go:generate mockery --name=VariantStorage --dir=. --output=./mocks --filename=variant_storage_mock.go --outpkg=mocks
+type VariantStorage interface {
+	URLOwner(alias string) (int64, error)
+	LinkVariants(alias string) ([]models.Variant, error)
+	SetVariants(alias string, variants []models.Variant) error
+}
+
+// Get returns split destinations of the alias
+func Get(log *slog.Logger, variantStorage VariantStorage, adminChecker access.AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.variants.Get"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, variantStorage, adminChecker)
+		if !ok {
+			return
+		}
+
+		list, err := variantStorage.LinkVariants(alias)
+		if err != nil {
+			log.Error("failed to get variants", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+			return
+		}
+
+		resp.NewJSON(w, r, http.StatusOK, Response{
+			Response: resp.OK(),
+			Variants: list,
+		})
+	}
+}
+
+// Put replaces split destinations of the alias.
+// Visitors whose variant is kept stay on it, the others are assigned again
+func Put(
+	log *slog.Logger,
+	variantStorage VariantStorage,
+	adminChecker access.AdminChecker,
+	checker urlpolicy.Checker,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.variants.Put"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, _, ok := access.Authorize(w, r, log, variantStorage, adminChecker)
+		if !ok {
+			return
+		}
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request body"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Error("invalid request", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		if len(req.Variants) == 1 {
+			log.Error("single variant")
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("at least two variants are required"))
+			return
+		}
+
+		list := make([]models.Variant, 0, len(req.Variants))
+		for _, v := range req.Variants {
+			if err := checker.Check(r.Context(), v.URL); err != nil {
+				log.Warn("target URL rejected", slog.String("url", v.URL), sl.Err(err))
+				resp.NewJSON(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+				return
+			}
+
+			list = append(list, models.Variant{Name: v.Name, URL: v.URL, Weight: v.Weight})
+		}
+
+		err := variantStorage.SetVariants(alias, list)
+		switch {
+		case errors.Is(err, storage.ErrAliasNotFound):
+			log.Error("alias not found", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+			return
+		case err != nil:
+			log.Error("failed to set variants", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to set variants"))
+			return
+		}
+
+		log.Info("variants set", slog.String("alias", alias), slog.Int("count", len(list)))
+
+		resp.NewJSON(w, r, http.StatusOK, Response{
+			Response: resp.OK(),
+			Variants: list,
+		})
+	}
+}
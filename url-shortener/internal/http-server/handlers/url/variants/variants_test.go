@@ -0,0 +1,129 @@
+package variants
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/url/variants/mocks"
+	accessmocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/access/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	policymocks "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 4
+
+func TestPutHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		blocked   error
+		saved     int
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "70/30 split",
+			body:     `{"variants":[{"name":"a","url":"https://example.com/a","weight":70},{"name":"b","url":"https://example.com/b","weight":30}]}`,
+			saved:    2,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Turn off",
+			body:     `{"variants":[]}`,
+			saved:    0,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Single variant",
+			body:      `{"variants":[{"name":"a","url":"https://example.com/a","weight":1}]}`,
+			saved:     -1,
+			respError: "at least two variants are required",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Duplicate names",
+			body:      `{"variants":[{"name":"a","url":"https://example.com/a","weight":1},{"name":"a","url":"https://example.com/b","weight":1}]}`,
+			saved:     -1,
+			respError: "field Variants must have unique Name",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Zero weight",
+			body:      `{"variants":[{"name":"a","url":"https://example.com/a","weight":0},{"name":"b","url":"https://example.com/b","weight":1}]}`,
+			saved:     -1,
+			respError: "field Weight must be greater than 0",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Private target",
+			body:      `{"variants":[{"name":"a","url":"https://example.com/a","weight":1},{"name":"b","url":"http://192.168.0.1","weight":1}]}`,
+			blocked:   urlpolicy.ErrDeniedAddress,
+			saved:     -1,
+			respError: "URL points to a private address",
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewVariantStorage(t)
+			checkerMock := policymocks.NewChecker(t)
+
+			storageMock.On("URLOwner", "ab").Return(int64(userID), nil).Once()
+			checkerMock.On("Check", mock.Anything, "http://192.168.0.1").Return(tc.blocked).Maybe()
+			checkerMock.On("Check", mock.Anything, mock.AnythingOfType("string")).Return(nil).Maybe()
+
+			if tc.saved >= 0 {
+				storageMock.On("SetVariants", "ab", mock.MatchedBy(func(list []models.Variant) bool {
+					return len(list) == tc.saved
+				})).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Put("/url/{alias}/variants", Put(slogdiscard.NewDiscardLogger(), storageMock, accessmocks.NewAdminChecker(t), checkerMock))
+
+			req := httptest.NewRequest(http.MethodPut, "/url/ab/variants", strings.NewReader(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+func TestGetHandler(t *testing.T) {
+	storageMock := mocks.NewVariantStorage(t)
+	adminMock := accessmocks.NewAdminChecker(t)
+
+	storageMock.On("URLOwner", "ab").Return(int64(userID+1), nil).Once()
+	adminMock.On("IsAdmin", mock.Anything, int64(userID)).Return(false, nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/variants", Get(slogdiscard.NewDiscardLogger(), storageMock, adminMock))
+
+	req := httptest.NewRequest(http.MethodGet, "/url/ab/variants", nil)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusForbidden, rr.Code)
+}
@@ -0,0 +1,241 @@
+package utm
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=50"`
+	// Default templates are applied to links created without a template
+	Default bool       `json:"default"`
+	UTM     models.UTM `json:"utm"`
+}
+
+type Response struct {
+	resp.Response
+	Template *models.UTMTemplate `json:"template,omitempty"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Templates []models.UTMTemplate `json:"templates"`
+}
+
+//go:generate mockery --name=TemplateStorage --dir=. --output=./mocks --filename=template_storage_mock.go --outpkg=mocks
+type TemplateStorage interface {
+	UTMTemplates(userID int64) ([]models.UTMTemplate, error)
+	CreateUTMTemplate(userID int64, t models.UTMTemplate) (models.UTMTemplate, error)
+	UpdateUTMTemplate(userID int64, t models.UTMTemplate) error
+	DeleteUTMTemplate(userID, templateID int64) error
+}
+
+// List returns campaign templates of the user
+func List(log *slog.Logger, templateStorage TemplateStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utm.List"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		list, err := templateStorage.UTMTemplates(int64(userID))
+		if err != nil {
+			log.Error("failed to list templates", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to list templates"))
+			return
+		}
+
+		resp.NewJSON(w, r, http.StatusOK, ListResponse{
+			Response:  resp.OK(),
+			Templates: list,
+		})
+	}
+}
+
+// Create saves a campaign template of the user
+func Create(log *slog.Logger, templateStorage TemplateStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utm.Create"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		t, ok := decode(w, r, log)
+		if !ok {
+			return
+		}
+
+		created, err := templateStorage.CreateUTMTemplate(int64(userID), t)
+		switch {
+		case errors.Is(err, storage.ErrTemplateExists):
+			log.Info("template already exists", slog.String("name", t.Name))
+			resp.NewJSON(w, r, http.StatusConflict, resp.Error("template already exists"))
+			return
+		case err != nil:
+			log.Error("failed to create template", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to create template"))
+			return
+		}
+
+		log.Info("template created", slog.Int64("id", created.ID))
+
+		resp.NewJSON(w, r, http.StatusCreated, Response{
+			Response: resp.OK(),
+			Template: &created,
+		})
+	}
+}
+
+// Update replaces the template, links created with it keep their targets
+func Update(log *slog.Logger, templateStorage TemplateStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utm.Update"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		templateID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.Error("invalid template id", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid template id"))
+			return
+		}
+
+		t, ok := decode(w, r, log)
+		if !ok {
+			return
+		}
+		t.ID = templateID
+
+		err = templateStorage.UpdateUTMTemplate(int64(userID), t)
+		switch {
+		case errors.Is(err, storage.ErrTemplateNotFound):
+			log.Info("template not found", slog.Int64("id", templateID))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("template not found"))
+			return
+		case errors.Is(err, storage.ErrTemplateExists):
+			log.Info("template already exists", slog.String("name", t.Name))
+			resp.NewJSON(w, r, http.StatusConflict, resp.Error("template already exists"))
+			return
+		case err != nil:
+			log.Error("failed to update template", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to update template"))
+			return
+		}
+
+		log.Info("template updated", slog.Int64("id", templateID))
+
+		resp.NewJSON(w, r, http.StatusOK, Response{
+			Response: resp.OK(),
+			Template: &t,
+		})
+	}
+}
+
+// Delete removes the template of the user
+func Delete(log *slog.Logger, templateStorage TemplateStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utm.Delete"
+
+		log = log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := mdjwt.GetUserID(r.Context())
+		if !ok {
+			resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+			return
+		}
+
+		templateID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.Error("invalid template id", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid template id"))
+			return
+		}
+
+		err = templateStorage.DeleteUTMTemplate(int64(userID), templateID)
+		switch {
+		case errors.Is(err, storage.ErrTemplateNotFound):
+			log.Info("template not found", slog.Int64("id", templateID))
+			resp.NewJSON(w, r, http.StatusNotFound, resp.Error("template not found"))
+			return
+		case err != nil:
+			log.Error("failed to delete template", sl.Err(err))
+			resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("failed to delete template"))
+			return
+		}
+
+		log.Info("template deleted", slog.Int64("id", templateID))
+		resp.NewJSON(w, r, http.StatusOK, resp.OK())
+	}
+}
+
+// decode reads the template from the request body
+// and writes the error response when it is invalid
+func decode(w http.ResponseWriter, r *http.Request, log *slog.Logger) (models.UTMTemplate, bool) {
+	var req Request
+
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("invalid request body"))
+		return models.UTMTemplate{}, false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+
+		log.Error("invalid request", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+		return models.UTMTemplate{}, false
+	}
+
+	if req.UTM.Empty() {
+		log.Error("template without parameters")
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("at least one utm parameter is required"))
+		return models.UTMTemplate{}, false
+	}
+
+	return models.UTMTemplate{Name: req.Name, Default: req.Default, UTM: req.UTM}, true
+}
@@ -0,0 +1,108 @@
+package utm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/http-server/handlers/utm/mocks"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = 11
+
+func TestCreateHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		mockError error
+		respError string
+		wantCode  int
+	}{
+		{
+			name:     "Success",
+			body:     `{"name":"newsletter","default":true,"utm":{"utm_source":"newsletter","utm_medium":"email"}}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:      "No parameters",
+			body:      `{"name":"empty","utm":{}}`,
+			respError: "at least one utm parameter is required",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Parameter too long",
+			body:      `{"name":"long","utm":{"utm_source":"` + strings.Repeat("a", 201) + `"}}`,
+			respError: "field Source must be at most 200",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Duplicate",
+			body:      `{"name":"newsletter","utm":{"utm_source":"newsletter"}}`,
+			mockError: storage.ErrTemplateExists,
+			respError: "template already exists",
+			wantCode:  http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewTemplateStorage(t)
+
+			if tc.respError == "" || tc.mockError != nil {
+				storageMock.On("CreateUTMTemplate", int64(userID), mock.MatchedBy(func(t models.UTMTemplate) bool {
+					return t.Name == "newsletter" && t.UTM.Source == "newsletter"
+				})).
+					Return(models.UTMTemplate{ID: 1, Name: "newsletter"}, tc.mockError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/utm-templates", Create(slogdiscard.NewDiscardLogger(), storageMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/utm-templates", strings.NewReader(tc.body))
+			req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var got Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			require.Equal(t, tc.respError, got.Error)
+		})
+	}
+}
+
+func TestUpdateHandler(t *testing.T) {
+	storageMock := mocks.NewTemplateStorage(t)
+	storageMock.On("UpdateUTMTemplate", int64(userID), mock.MatchedBy(func(t models.UTMTemplate) bool {
+		return t.ID == 3 && t.UTM.Campaign == "spring"
+	})).
+		Return(storage.ErrTemplateNotFound).
+		Once()
+
+	r := chi.NewRouter()
+	r.Put("/utm-templates/{id}", Update(slogdiscard.NewDiscardLogger(), storageMock))
+
+	body := strings.NewReader(`{"name":"sale","utm":{"utm_campaign":"spring"}}`)
+	req := httptest.NewRequest(http.MethodPut, "/utm-templates/3", body)
+	req = req.WithContext(mdjwt.WithUserID(req.Context(), userID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,102 @@
+package realip
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// New sets the remote address of the request from X-Forwarded-For or X-Real-IP,
+// but only when the connection comes from one of the trusted proxies.
+// Headers of other clients are ignored, they could put any address there
+func New(trusted []netip.Prefix) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if ip, ok := forwardedIP(r, trusted); ok {
+				r.RemoteAddr = ip.String()
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ParsePrefixes reads CIDRs of the trusted proxies, single addresses are allowed too
+func ParsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, err
+			}
+
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// forwardedIP walks X-Forwarded-For from the right and returns the first address
+// that is not a trusted proxy, X-Real-IP is used when there is no X-Forwarded-For
+func forwardedIP(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	remote, ok := parseIP(r.RemoteAddr)
+	if !ok || !contains(trusted, remote) {
+		return netip.Addr{}, false
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip, ok := parseIP(strings.TrimSpace(hops[i]))
+			if !ok {
+				return netip.Addr{}, false
+			}
+			if !contains(trusted, ip) {
+				return ip, true
+			}
+		}
+
+		return netip.Addr{}, false
+	}
+
+	return parseIP(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+}
+
+// parseIP accepts an address with or without a port
+func parseIP(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return ip.Unmap(), true
+}
+
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package realip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted, err := ParsePrefixes([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:   "Direct client",
+			remote: "203.0.113.7:5000",
+			want:   "203.0.113.7:5000",
+		},
+		{
+			name:    "Untrusted client with forwarded header",
+			remote:  "203.0.113.7:5000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:    "203.0.113.7:5000",
+		},
+		{
+			name:    "Untrusted client with real ip header",
+			remote:  "203.0.113.7:5000",
+			headers: map[string]string{"X-Real-IP": "198.51.100.1"},
+			want:    "203.0.113.7:5000",
+		},
+		{
+			name:    "Trusted proxy",
+			remote:  "10.1.2.3:5000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "Spoofed hops before the proxy",
+			remote:  "10.1.2.3:5000",
+			headers: map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 192.168.1.1"},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "Trusted proxy with real ip header",
+			remote:  "192.168.1.1:5000",
+			headers: map[string]string{"X-Real-IP": "198.51.100.1"},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "Malformed forwarded header",
+			remote:  "10.1.2.3:5000",
+			headers: map[string]string{"X-Forwarded-For": "not-an-ip"},
+			want:    "10.1.2.3:5000",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+
+			handler := New(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remote
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	_, err := ParsePrefixes([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+
+	_, err = ParsePrefixes([]string{"proxy"})
+	require.Error(t, err)
+}
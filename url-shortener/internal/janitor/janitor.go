@@ -0,0 +1,201 @@
+package janitor
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+)
+
+//go:generate mockery --name=LinkPurger --dir=. --output=./mocks --filename=link_purger_mock.go --outpkg=mocks
+type LinkPurger interface {
+	PurgeExpired(now time.Time, archive bool, limit int) ([]models.Link, error)
+	PurgeDeleted(before time.Time, limit int) ([]models.Link, error)
+	ActivateScheduled(now time.Time, limit int) ([]models.Link, error)
+}
+
+//go:generate mockery --name=ProducerProvider --dir=. --output=./mocks --filename=producer_provider_mock.go --outpkg=mocks
+type ProducerProvider interface {
+	Publish(ctx context.Context, key string, value interface{}) error
+	Close() error
+}
+
+// Janitor periodically removes expired links and links kept in the trash
+// for longer than the retention, publishing link.expired and link.purged events.
+// It also announces scheduled links that went live with link.activated events
+type Janitor struct {
+	log       *slog.Logger
+	purger    LinkPurger
+	producer  ProducerProvider
+	interval  time.Duration
+	archive   bool
+	batchSize int
+	retention time.Duration
+}
+
+func New(
+	log *slog.Logger,
+	purger LinkPurger,
+	producer ProducerProvider,
+	interval time.Duration,
+	archive bool,
+	batchSize int,
+	retention time.Duration,
+) *Janitor {
+	return &Janitor{
+		log:       log.With(slog.String("component", "janitor")),
+		purger:    purger,
+		producer:  producer,
+		interval:  interval,
+		archive:   archive,
+		batchSize: batchSize,
+		retention: retention,
+	}
+}
+
+// Start runs the janitor in background until ctx is done
+func (j *Janitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				j.log.Info("janitor stopped")
+				return
+			case <-ticker.C:
+				j.Activate(ctx)
+				j.Purge(ctx)
+			}
+		}
+	}()
+}
+
+// Purge removes expired and trashed links batch by batch until none are left
+func (j *Janitor) Purge(ctx context.Context) {
+	j.purgeExpired(ctx)
+	j.purgeDeleted(ctx)
+}
+
+// Activate publishes link.activated for scheduled links whose launch time has come
+func (j *Janitor) Activate(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+
+		links, err := j.purger.ActivateScheduled(now, j.batchSize)
+		if err != nil {
+			j.log.Error("failed to activate scheduled links", sl.Err(err))
+			return
+		}
+
+		for _, link := range links {
+			ev := map[string]interface{}{
+				"type":       kafka.EventLinkActivated,
+				"timestamp":  now,
+				"user_id":    link.UserID,
+				"alias":      link.Ref(),
+				"url":        link.URL,
+				"link_id":    link.ID,
+				"not_before": link.NotBefore,
+			}
+
+			if err := j.producer.Publish(ctx, strconv.FormatInt(link.UserID, 10), ev); err != nil {
+				j.log.Error("failed to send message to Kafka", sl.Err(err))
+			}
+		}
+
+		if len(links) > 0 {
+			j.log.Info("scheduled links activated", slog.Int("count", len(links)))
+		}
+
+		if len(links) < j.batchSize {
+			return
+		}
+	}
+}
+
+func (j *Janitor) purgeExpired(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+
+		links, err := j.purger.PurgeExpired(now, j.archive, j.batchSize)
+		if err != nil {
+			j.log.Error("failed to purge expired links", sl.Err(err))
+			return
+		}
+
+		for _, link := range links {
+			j.publish(ctx, link, now)
+		}
+
+		if len(links) > 0 {
+			j.log.Info("expired links purged", slog.Int("count", len(links)), slog.Bool("archive", j.archive))
+		}
+
+		if len(links) < j.batchSize {
+			return
+		}
+	}
+}
+
+func (j *Janitor) purgeDeleted(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+
+		links, err := j.purger.PurgeDeleted(now.Add(-j.retention), j.batchSize)
+		if err != nil {
+			j.log.Error("failed to purge deleted links", sl.Err(err))
+			return
+		}
+
+		for _, link := range links {
+			ev := map[string]interface{}{
+				"type":       kafka.EventLinkPurged,
+				"timestamp":  now,
+				"user_id":    link.UserID,
+				"alias":      link.Ref(),
+				"url":        link.URL,
+				"link_id":    link.ID,
+				"deleted_at": link.DeletedAt,
+			}
+
+			if err := j.producer.Publish(ctx, strconv.FormatInt(link.UserID, 10), ev); err != nil {
+				j.log.Error("failed to send message to Kafka", sl.Err(err))
+			}
+		}
+
+		if len(links) > 0 {
+			j.log.Info("deleted links purged", slog.Int("count", len(links)))
+		}
+
+		if len(links) < j.batchSize {
+			return
+		}
+	}
+}
+
+func (j *Janitor) publish(ctx context.Context, link models.Link, now time.Time) {
+	reason := "max_clicks"
+	if link.Expired(now) {
+		reason = "expires_at"
+	}
+
+	ev := map[string]interface{}{
+		"type":      kafka.EventLinkExpired,
+		"timestamp": now,
+		"user_id":   link.UserID,
+		"alias":     link.Ref(),
+		"url":       link.URL,
+		"link_id":   link.ID,
+		"reason":    reason,
+	}
+
+	if err := j.producer.Publish(ctx, strconv.FormatInt(link.UserID, 10), ev); err != nil {
+		j.log.Error("failed to send message to Kafka", sl.Err(err))
+	}
+}
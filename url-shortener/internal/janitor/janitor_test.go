@@ -0,0 +1,89 @@
+package janitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/common/logger/slogdiscard"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/janitor/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJanitorPurge(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	maxClicks := int64(1)
+
+	purgerMock := mocks.NewLinkPurger(t)
+	producerMock := mocks.NewProducerProvider(t)
+
+	// a full batch makes the janitor ask for the next one
+	purgerMock.On("PurgeExpired", mock.AnythingOfType("time.Time"), true, 2).
+		Return([]models.Link{
+			{ID: 1, Alias: "old", UserID: 3, ExpiresAt: &past},
+			{ID: 2, Alias: "used", Domain: "go.example.com", UserID: 3, MaxClicks: &maxClicks, Clicks: 1},
+		}, nil).
+		Once()
+	purgerMock.On("PurgeExpired", mock.AnythingOfType("time.Time"), true, 2).
+		Return(nil, nil).
+		Once()
+
+	// links on custom domains are reported by their ref like in the other link events
+	for reason, alias := range map[string]string{"expires_at": "old", "max_clicks": "go.example.com/used"} {
+		reason, alias := reason, alias
+		producerMock.On("Publish", mock.Anything, "3", mock.MatchedBy(func(ev map[string]interface{}) bool {
+			return ev["type"] == kafka.EventLinkExpired && ev["reason"] == reason && ev["alias"] == alias
+		})).
+			Return(nil).
+			Once()
+	}
+
+	purgerMock.On("PurgeDeleted", mock.AnythingOfType("time.Time"), 2).Return(nil, nil).Once()
+
+	New(slogdiscard.NewDiscardLogger(), purgerMock, producerMock, time.Minute, true, 2, time.Hour).Purge(context.Background())
+}
+
+func TestJanitorPurgeDeleted(t *testing.T) {
+	deletedAt := time.Now().Add(-48 * time.Hour)
+
+	purgerMock := mocks.NewLinkPurger(t)
+	producerMock := mocks.NewProducerProvider(t)
+
+	purgerMock.On("PurgeExpired", mock.AnythingOfType("time.Time"), false, 10).Return(nil, nil).Once()
+
+	// only links deleted before the retention are purged
+	purgerMock.On("PurgeDeleted", mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 24*time.Hour && time.Since(before) < 25*time.Hour
+	}), 10).
+		Return([]models.Link{{ID: 5, Alias: "gone", Domain: "go.example.com", UserID: 8, DeletedAt: &deletedAt}}, nil).
+		Once()
+
+	producerMock.On("Publish", mock.Anything, "8", mock.MatchedBy(func(ev map[string]interface{}) bool {
+		return ev["type"] == kafka.EventLinkPurged && ev["alias"] == "go.example.com/gone"
+	})).
+		Return(nil).
+		Once()
+
+	New(slogdiscard.NewDiscardLogger(), purgerMock, producerMock, time.Minute, false, 10, 24*time.Hour).Purge(context.Background())
+}
+
+func TestJanitorActivate(t *testing.T) {
+	launch := time.Now().Add(-time.Minute)
+
+	purgerMock := mocks.NewLinkPurger(t)
+	producerMock := mocks.NewProducerProvider(t)
+
+	purgerMock.On("ActivateScheduled", mock.AnythingOfType("time.Time"), 10).
+		Return([]models.Link{{ID: 4, Alias: "launch", Domain: "go.example.com", UserID: 6, NotBefore: &launch}}, nil).
+		Once()
+
+	producerMock.On("Publish", mock.Anything, "6", mock.MatchedBy(func(ev map[string]interface{}) bool {
+		return ev["type"] == kafka.EventLinkActivated && ev["alias"] == "go.example.com/launch"
+	})).
+		Return(nil).
+		Once()
+
+	New(slogdiscard.NewDiscardLogger(), purgerMock, producerMock, time.Minute, false, 10, time.Hour).Activate(context.Background())
+}
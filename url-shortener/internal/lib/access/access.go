@@ -0,0 +1,115 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	resp "github.com/lostmyescape/link-shortener/url-shortener/internal/lib/api/response"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/jwt/mdjwt"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+// AdminChecker reports whether the user may manage links of other users
+//
+//go:generate mockery --name=AdminChecker --dir=. --output=./mocks --filename=admin_checker_mock.go --outpkg=mocks
+type AdminChecker interface {
+	IsAdmin(ctx context.Context, userID int64) (bool, error)
+}
+
+// OwnerFinder finds the user who created the link of the alias
+type OwnerFinder interface {
+	URLOwner(alias string) (int64, error)
+}
+
+// CanManage reports whether the user may change the link created by ownerID:
+// owners manage their own links, admins manage any link
+func CanManage(ctx context.Context, checker AdminChecker, ownerID, userID int64) (bool, error) {
+	const op = "lib.access.CanManage"
+
+	if ownerID == userID {
+		return true, nil
+	}
+
+	isAdmin, err := checker.IsAdmin(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return isAdmin, nil
+}
+
+// Authorize checks that the user of the request may manage the link of the alias URL parameter
+// and writes the error response when not. It returns the alias and the id of the user
+func Authorize(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	owners OwnerFinder,
+	checker AdminChecker,
+) (string, int, bool) {
+	userID, ok := mdjwt.GetUserID(r.Context())
+	if !ok {
+		resp.NewJSON(w, r, http.StatusUnauthorized, resp.Error("unauthorized"))
+		return "", 0, false
+	}
+
+	alias := chi.URLParam(r, "alias")
+	if alias == "" {
+		log.Error("alias is empty")
+		resp.NewJSON(w, r, http.StatusBadRequest, resp.Error("alias is empty"))
+		return "", 0, false
+	}
+
+	ownerID, err := owners.URLOwner(alias)
+	switch {
+	case errors.Is(err, storage.ErrAliasNotFound):
+		log.Error("alias not found", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusNotFound, resp.Error("alias not found"))
+		return "", 0, false
+	case err != nil:
+		log.Error("failed to get alias owner", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+		return "", 0, false
+	}
+
+	if !Allow(w, r, log, checker, ownerID, userID, alias) {
+		return "", 0, false
+	}
+
+	return alias, userID, true
+}
+
+// Allow writes the error response and returns false unless the user may manage
+// the link of the alias created by ownerID, see CanManage
+func Allow(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	checker AdminChecker,
+	ownerID int64,
+	userID int,
+	alias string,
+) bool {
+	allowed, err := CanManage(r.Context(), checker, ownerID, int64(userID))
+	if err != nil {
+		log.Error("failed to check admin rights", sl.Err(err))
+		resp.NewJSON(w, r, http.StatusInternalServerError, resp.Error("unexpected error"))
+		return false
+	}
+
+	if !allowed {
+		log.Warn("attempt to manage someone else's link",
+			slog.String("alias", alias),
+			slog.Int("user_id", userID),
+		)
+		resp.NewJSON(w, r, http.StatusForbidden, resp.Error("forbidden"))
+		return false
+	}
+
+	return true
+}
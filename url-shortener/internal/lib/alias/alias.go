@@ -0,0 +1,200 @@
+package alias
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+)
+
+// Validation tags reported by resp.ValidationError
+const (
+	Tag         = "alias"
+	TagLength   = "alias_length"
+	TagCharset  = "alias_charset"
+	TagReserved = "alias_reserved"
+	TagBlocked  = "alias_blocked"
+)
+
+// ErrRejected is returned when the generator keeps making aliases the validator forbids
+var ErrRejected = errors.New("generated alias is not allowed")
+
+const DefaultCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"
+
+type Options struct {
+	Charset       string
+	MinLength     int
+	MaxLength     int
+	Reserved      []string
+	BlocklistPath string
+}
+
+// Validator checks custom aliases against the configured rules
+type Validator struct {
+	charset   map[rune]struct{}
+	minLength int
+	maxLength int
+	reserved  map[string]struct{}
+	blocked   []string
+}
+
+func NewValidator(opts Options) (*Validator, error) {
+	const op = "lib.alias.NewValidator"
+
+	if opts.Charset == "" {
+		opts.Charset = DefaultCharset
+	}
+
+	v := &Validator{
+		charset:   make(map[rune]struct{}, len(opts.Charset)),
+		minLength: opts.MinLength,
+		maxLength: opts.MaxLength,
+		reserved:  make(map[string]struct{}, len(opts.Reserved)),
+	}
+
+	for _, r := range opts.Charset {
+		v.charset[r] = struct{}{}
+	}
+
+	v.Reserve(opts.Reserved...)
+
+	if opts.BlocklistPath != "" {
+		blocked, err := readBlocklist(opts.BlocklistPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		v.blocked = blocked
+	}
+
+	return v, nil
+}
+
+// Reserve forbids the words as aliases, case-insensitively.
+// It must be called before the validator is used by handlers
+func (v *Validator) Reserve(words ...string) {
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			v.reserved[w] = struct{}{}
+		}
+	}
+}
+
+// Register adds the alias tags to validate.
+// The "alias" tag runs all of them, so requests only need validate:"omitempty,alias"
+func (v *Validator) Register(validate *validator.Validate) {
+	rules := map[string]func(string) bool{
+		TagLength:   v.validLength,
+		TagCharset:  v.validCharset,
+		TagReserved: v.notReserved,
+		TagBlocked:  v.notBlocked,
+	}
+
+	for tag, rule := range rules {
+		rule := rule
+		_ = validate.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return rule(fl.Field().String())
+		})
+	}
+
+	validate.RegisterAlias(Tag, strings.Join([]string{TagLength, TagCharset, TagReserved, TagBlocked}, ","))
+}
+
+// Generate fills the alias of the link with generator and makes it again while it is
+// a reserved word or contains a blocked one, up to MaxAttempts times. Generated aliases
+// skip the validation of requests, so without it they could shadow routes of the service
+func (v *Validator) Generate(generator Generator, link *models.Link) error {
+	for attempt := 1; ; attempt++ {
+		if err := generator.Generate(link); err != nil {
+			return err
+		}
+
+		if v.notReserved(link.Alias) && v.notBlocked(link.Alias) {
+			return nil
+		}
+
+		if attempt >= MaxAttempts {
+			return fmt.Errorf("%w: %s", ErrRejected, link.Alias)
+		}
+	}
+}
+
+func (v *Validator) validLength(alias string) bool {
+	n := utf8.RuneCountInString(alias)
+	return n >= v.minLength && (v.maxLength <= 0 || n <= v.maxLength)
+}
+
+func (v *Validator) validCharset(alias string) bool {
+	for _, r := range alias {
+		if _, ok := v.charset[r]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (v *Validator) notReserved(alias string) bool {
+	_, ok := v.reserved[strings.ToLower(alias)]
+	return !ok
+}
+
+func (v *Validator) notBlocked(alias string) bool {
+	lower := strings.ToLower(alias)
+	for _, word := range v.blocked {
+		if strings.Contains(lower, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReservedFromRoutes returns the first static segment of every route,
+// so an alias can never shadow an endpoint of the service
+func ReservedFromRoutes(routes chi.Routes) []string {
+	var words []string
+
+	_ = chi.Walk(routes, func(_ string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if segment != "" && !strings.ContainsAny(segment, "{*") {
+			words = append(words, segment)
+		}
+
+		return nil
+	})
+
+	return words
+}
+
+// readBlocklist loads one word per line, lines starting with # are comments
+func readBlocklist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return words, nil
+}
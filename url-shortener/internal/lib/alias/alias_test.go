@@ -0,0 +1,94 @@
+package alias
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidator(t *testing.T) {
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(blocklist, []byte("# comment\nbadword\n"), 0o600))
+
+	v, err := NewValidator(Options{MinLength: 3, MaxLength: 10, Reserved: []string{"Admin"}, BlocklistPath: blocklist})
+	require.NoError(t, err)
+
+	validate := validator.New()
+	v.Register(validate)
+
+	tests := []struct {
+		alias   string
+		wantTag string
+	}{
+		{alias: "promo-2024"},
+		{alias: "ab", wantTag: TagLength},
+		{alias: "a b c", wantTag: TagCharset},
+		{alias: "тест", wantTag: TagCharset},
+		{alias: "ADMIN", wantTag: TagReserved},
+		{alias: "myBadWord", wantTag: TagBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			err := validate.Var(tt.alias, Tag)
+			if tt.wantTag == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var validateErr validator.ValidationErrors
+			require.ErrorAs(t, err, &validateErr)
+			assert.Equal(t, tt.wantTag, validateErr[0].ActualTag())
+		})
+	}
+}
+
+// listGenerator hands out the aliases in order
+type listGenerator struct {
+	aliases []string
+}
+
+func (g *listGenerator) Generate(link *models.Link) error {
+	link.Alias, g.aliases = g.aliases[0], g.aliases[1:]
+	return nil
+}
+
+func TestValidatorGenerate(t *testing.T) {
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(blocklist, []byte("badword\n"), 0o600))
+
+	v, err := NewValidator(Options{Reserved: []string{"url", "login"}, BlocklistPath: blocklist})
+	require.NoError(t, err)
+
+	var link models.Link
+	require.NoError(t, v.Generate(&listGenerator{aliases: []string{"URL", "xbadwordx", "promo"}}, &link))
+	require.Equal(t, "promo", link.Alias)
+
+	rejected := make([]string, MaxAttempts)
+	for i := range rejected {
+		rejected[i] = "login"
+	}
+	require.ErrorIs(t, v.Generate(&listGenerator{aliases: rejected}, &link), ErrRejected)
+}
+
+func TestReservedFromRoutes(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	r := chi.NewRouter()
+	r.Route("/url", func(r chi.Router) {
+		r.Get("/", noop)
+		r.Get("/{alias}/qr", noop)
+	})
+	r.Get("/{alias}", noop)
+	r.Post("/login", noop)
+	r.Get("/refresh", noop)
+
+	assert.ElementsMatch(t, []string{"url", "url", "login", "refresh"}, ReservedFromRoutes(r))
+}
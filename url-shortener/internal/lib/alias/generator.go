@@ -0,0 +1,245 @@
+package alias
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+)
+
+// Generation strategies selectable in config
+const (
+	StrategyRandom     = "random"
+	StrategySequential = "sequential"
+	StrategyHashids    = "hashids"
+	StrategyWords      = "words"
+)
+
+const (
+	// MaxAttempts limits retries of generated aliases that collide with existing ones
+	MaxAttempts = 5
+
+	defaultLength = 7
+)
+
+const base62 = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+var ErrUnknownStrategy = errors.New("unknown alias generation strategy")
+
+// Generator fills the alias of a link created without a custom one.
+// ID based strategies also set the reserved link.ID the alias is derived from
+type Generator interface {
+	Generate(link *models.Link) error
+}
+
+// IDSource reserves ids of links before they are saved
+type IDSource interface {
+	NextLinkID() (int64, error)
+}
+
+type GeneratorOptions struct {
+	Strategy string
+	Length   int
+	Salt     string
+}
+
+// NewGenerator builds the generator of the strategy, ids is used by sequential and hashids
+func NewGenerator(opts GeneratorOptions, ids IDSource) (Generator, error) {
+	const op = "lib.alias.NewGenerator"
+
+	if opts.Length <= 0 {
+		opts.Length = defaultLength
+	}
+
+	switch opts.Strategy {
+	case "", StrategyRandom:
+		return Random{Length: opts.Length}, nil
+	case StrategySequential:
+		return Sequential{IDs: ids}, nil
+	case StrategyHashids:
+		return NewHashids(ids, opts.Salt, opts.Length), nil
+	case StrategyWords:
+		return Words{}, nil
+	default:
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrUnknownStrategy, opts.Strategy)
+	}
+}
+
+// Random makes base62 aliases from crypto/rand
+type Random struct {
+	Length int
+}
+
+func (g Random) Generate(link *models.Link) error {
+	alias, err := randomString(base62, g.Length)
+	if err != nil {
+		return fmt.Errorf("lib.alias.Random: %w", err)
+	}
+
+	link.Alias = alias
+
+	return nil
+}
+
+// Sequential encodes the id of the link in base62, giving the shortest possible aliases
+type Sequential struct {
+	IDs IDSource
+}
+
+func (g Sequential) Generate(link *models.Link) error {
+	id, err := g.IDs.NextLinkID()
+	if err != nil {
+		return fmt.Errorf("lib.alias.Sequential: %w", err)
+	}
+
+	link.ID = id
+	link.Alias = encode(uint64(id), base62)
+
+	return nil
+}
+
+// Hashids encodes the id of the link with an alphabet shuffled by the salt,
+// so aliases stay short but neighbouring links do not look alike
+type Hashids struct {
+	ids       IDSource
+	alphabet  string
+	salt      string
+	minLength int
+}
+
+func NewHashids(ids IDSource, salt string, minLength int) Hashids {
+	return Hashids{
+		ids:       ids,
+		alphabet:  shuffle(base62, salt),
+		salt:      salt,
+		minLength: minLength,
+	}
+}
+
+func (g Hashids) Generate(link *models.Link) error {
+	id, err := g.ids.NextLinkID()
+	if err != nil {
+		return fmt.Errorf("lib.alias.Hashids: %w", err)
+	}
+
+	link.ID = id
+	link.Alias = g.Encode(uint64(id))
+
+	return nil
+}
+
+// Encode turns the id into its alias. The first character picks the alphabet
+// for the rest, short results are padded with leading zero digits
+func (g Hashids) Encode(id uint64) string {
+	lottery := g.alphabet[id%uint64(len(g.alphabet))]
+	alphabet := shuffle(g.alphabet, string(lottery)+g.salt)
+
+	body := encode(id, alphabet)
+	if pad := g.minLength - 1 - len(body); pad > 0 {
+		body = strings.Repeat(alphabet[:1], pad) + body
+	}
+
+	return string(lottery) + body
+}
+
+// Words builds readable aliases like brave-otter-42
+type Words struct{}
+
+func (Words) Generate(link *models.Link) error {
+	adjective, err := randomInt(len(adjectives))
+	if err != nil {
+		return fmt.Errorf("lib.alias.Words: %w", err)
+	}
+	noun, err := randomInt(len(nouns))
+	if err != nil {
+		return fmt.Errorf("lib.alias.Words: %w", err)
+	}
+	number, err := randomInt(100)
+	if err != nil {
+		return fmt.Errorf("lib.alias.Words: %w", err)
+	}
+
+	link.Alias = fmt.Sprintf("%s-%s-%d", adjectives[adjective], nouns[noun], number)
+
+	return nil
+}
+
+func encode(n uint64, alphabet string) string {
+	if n == 0 {
+		return alphabet[:1]
+	}
+
+	base := uint64(len(alphabet))
+
+	var b []byte
+	for ; n > 0; n /= base {
+		b = append(b, alphabet[n%base])
+	}
+
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+
+	return string(b)
+}
+
+// shuffle permutes the alphabet deterministically by the salt, as Hashids does
+func shuffle(alphabet, salt string) string {
+	if salt == "" {
+		return alphabet
+	}
+
+	a := []byte(alphabet)
+	for i, v, p := len(a)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		p += int(salt[v])
+		j := (int(salt[v]) + v + p) % i
+		a[i], a[j] = a[j], a[i]
+		v++
+	}
+
+	return string(a)
+}
+
+func randomString(alphabet string, length int) (string, error) {
+	b := make([]byte, length)
+	for i := range b {
+		n, err := randomInt(len(alphabet))
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[n]
+	}
+
+	return string(b), nil
+}
+
+func randomInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n.Int64()), nil
+}
+
+var adjectives = []string{
+	"amber", "bold", "brave", "bright", "calm", "clever", "cosy", "crisp",
+	"eager", "fancy", "fast", "fresh", "gentle", "glad", "golden", "grand",
+	"happy", "jolly", "keen", "kind", "lively", "lucky", "mellow", "merry",
+	"mighty", "neat", "noble", "proud", "quick", "quiet", "rapid", "royal",
+	"shiny", "silent", "silver", "smart", "snowy", "solid", "sunny", "swift",
+	"tidy", "vivid", "warm", "wild", "wise", "witty", "young", "zesty",
+}
+
+var nouns = []string{
+	"badger", "beaver", "bison", "canyon", "cedar", "comet", "coral", "crane",
+	"delta", "eagle", "falcon", "forest", "fox", "glacier", "harbor", "hawk",
+	"island", "lagoon", "lemur", "lion", "lynx", "maple", "meadow", "meteor",
+	"moose", "nebula", "ocean", "orchid", "otter", "owl", "panda", "pebble",
+	"pine", "planet", "prairie", "quartz", "raven", "reef", "river", "robin",
+	"salmon", "spruce", "summit", "tiger", "tulip", "valley", "walrus", "willow",
+}
@@ -0,0 +1,71 @@
+package alias
+
+import (
+	"testing"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type idSource struct {
+	next int64
+}
+
+func (s *idSource) NextLinkID() (int64, error) {
+	s.next++
+	return s.next, nil
+}
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		strategy string
+		pattern  string
+		wantID   bool
+	}{
+		{strategy: StrategyRandom, pattern: `^[0-9a-zA-Z]{7}$`},
+		{strategy: StrategySequential, pattern: `^[0-9a-zA-Z]+$`, wantID: true},
+		{strategy: StrategyHashids, pattern: `^[0-9a-zA-Z]{7}$`, wantID: true},
+		{strategy: StrategyWords, pattern: `^[a-z]+-[a-z]+-\d{1,2}$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			gen, err := NewGenerator(GeneratorOptions{Strategy: tt.strategy, Salt: "pepper"}, &idSource{next: 61})
+			require.NoError(t, err)
+
+			var link models.Link
+			require.NoError(t, gen.Generate(&link))
+
+			assert.Regexp(t, tt.pattern, link.Alias)
+			assert.Equal(t, tt.wantID, link.ID != 0)
+		})
+	}
+}
+
+func TestSequentialEncoding(t *testing.T) {
+	gen := Sequential{IDs: &idSource{next: 61}}
+
+	var link models.Link
+	require.NoError(t, gen.Generate(&link))
+	assert.Equal(t, "10", link.Alias)
+	assert.Equal(t, int64(62), link.ID)
+}
+
+func TestHashidsUnique(t *testing.T) {
+	h := NewHashids(nil, "pepper", 5)
+
+	seen := make(map[string]uint64)
+	for id := uint64(1); id < 50000; id++ {
+		alias := h.Encode(id)
+		if prev, ok := seen[alias]; ok {
+			t.Fatalf("ids %d and %d share alias %s", prev, id, alias)
+		}
+		seen[alias] = id
+	}
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	_, err := NewGenerator(GeneratorOptions{Strategy: "uuid"}, nil)
+	require.ErrorIs(t, err, ErrUnknownStrategy)
+}
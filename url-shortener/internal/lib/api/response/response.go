@@ -53,6 +53,32 @@ func ValidationError(errs validator.ValidationErrors) Response {
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
 		case "url":
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
+		case "gt":
+			if err.Param() == "" {
+				errMsgs = append(errMsgs, fmt.Sprintf("field %s must be in the future", err.Field()))
+			} else {
+				errMsgs = append(errMsgs, fmt.Sprintf("field %s must be greater than %s", err.Field(), err.Param()))
+			}
+		case "gtfield":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be after %s", err.Field(), err.Param()))
+		case "unique":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must have unique %s", err.Field(), err.Param()))
+		case "max":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be at most %s", err.Field(), err.Param()))
+		case "oneof":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be one of %s", err.Field(), err.Param()))
+		case "password":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be at most 72 bytes", err.Field()))
+		case "fqdn":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid hostname", err.Field()))
+		case "alias_length":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s has invalid length", err.Field()))
+		case "alias_charset":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s contains forbidden characters", err.Field()))
+		case "alias_reserved":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s is reserved", err.Field()))
+		case "alias_blocked":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s contains a blocked word", err.Field()))
 		default:
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid", err.Field()))
 		}
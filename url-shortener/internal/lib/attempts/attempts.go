@@ -0,0 +1,47 @@
+package attempts
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AttemptStore counts failed attempts per key within a fixed window
+type AttemptStore struct {
+	rdb    *redis.Client
+	max    int64
+	window time.Duration
+}
+
+func NewRedisStore(rdb *redis.Client, max int, window time.Duration) *AttemptStore {
+	return &AttemptStore{
+		rdb:    rdb,
+		max:    int64(max),
+		window: window,
+	}
+}
+
+// Attempt registers an attempt and reports whether the key went over the limit.
+// The count is taken and increased in one step, so parallel attempts cannot all pass
+// the check before any of them is counted. The window starts with the first attempt
+func (s *AttemptStore) Attempt(ctx context.Context, key string) (bool, error) {
+	pipe := s.rdb.TxPipeline()
+	count := pipe.Incr(ctx, getKey(key))
+	pipe.ExpireNX(ctx, getKey(key), s.window)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+
+	return count.Val() > s.max, nil
+}
+
+// Reset forgets failed attempts of the key
+func (s *AttemptStore) Reset(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, getKey(key)).Err()
+}
+
+func getKey(key string) string {
+	return "attempts:" + key
+}
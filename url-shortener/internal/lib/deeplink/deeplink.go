@@ -0,0 +1,23 @@
+package deeplink
+
+import (
+	"context"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+)
+
+// Check rejects unsafe app URIs and web URLs of the deep link the checker does not allow
+func Check(ctx context.Context, checker urlpolicy.Checker, deepLink models.DeepLink) error {
+	if err := deepLink.Validate(); err != nil {
+		return err
+	}
+
+	for _, u := range deepLink.WebURLs() {
+		if err := checker.Check(ctx, u); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package deeplink
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/stretchr/testify/require"
+)
+
+var errDenied = errors.New("denied")
+
+// hostChecker denies the listed URLs
+type hostChecker map[string]bool
+
+func (c hostChecker) Check(_ context.Context, rawURL string) error {
+	if c[rawURL] {
+		return errDenied
+	}
+
+	return nil
+}
+
+func TestCheck(t *testing.T) {
+	checker := hostChecker{"http://10.0.0.1/app": true}
+
+	cases := []struct {
+		name     string
+		deepLink models.DeepLink
+		want     error
+	}{
+		{name: "App scheme", deepLink: models.DeepLink{AppURI: "myapp://promo", IOSStoreURL: "https://apps.apple.com/app/id1"}},
+		{name: "Unsafe scheme", deepLink: models.DeepLink{AppURI: "javascript://%0aalert(1)"}, want: models.ErrAppURIScheme},
+		{name: "Denied universal link", deepLink: models.DeepLink{AppURI: "http://10.0.0.1/app"}, want: errDenied},
+		{name: "Denied store", deepLink: models.DeepLink{AppURI: "myapp://promo", AndroidStoreURL: "http://10.0.0.1/app"}, want: errDenied},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorIs(t, Check(context.Background(), checker, tc.deepLink), tc.want)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package domainverify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+)
+
+// Methods of proving control of a domain
+const (
+	MethodDNS  = "dns"
+	MethodHTTP = "http"
+)
+
+const (
+	// RecordName is prepended to the host to get the name of the TXT record
+	RecordName = "_shortener-challenge"
+	// RecordPrefix is followed by the token in the value of the TXT record
+	RecordPrefix = "shortener-verification="
+	// FilePath is served by the host with the token as its content
+	FilePath = "/.well-known/shortener-verification.txt"
+
+	defaultTimeout = 5 * time.Second
+	maxFileSize    = 1024
+)
+
+var (
+	ErrUnknownMethod = errors.New("unknown verification method")
+	ErrNotVerified   = errors.New("verification token not found")
+)
+
+// Resolver looks up TXT records, *net.Resolver satisfies it
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Control is run by the dialer before every connection, see net.Dialer
+type Control func(network, address string, c syscall.RawConn) error
+
+type Options struct {
+	// Resolver answers the DNS challenge, net.DefaultResolver when nil
+	Resolver Resolver
+	// Client fetches the HTTP challenge, redirects are not followed by the default one
+	Client *http.Client
+	// Checker is asked before the HTTP challenge is fetched, nil allows any host
+	Checker urlpolicy.Checker
+	// Control rejects addresses the default client must not connect to. Checker resolves
+	// the host on its own, so only Control sees the address that is really dialed
+	Control Control
+	Timeout time.Duration
+}
+
+// Verifier checks that the owner of a domain has published its token
+type Verifier struct {
+	resolver Resolver
+	client   *http.Client
+	checker  urlpolicy.Checker
+	timeout  time.Duration
+}
+
+func New(opts Options) *Verifier {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	if opts.Resolver == nil {
+		opts.Resolver = net.DefaultResolver
+	}
+
+	if opts.Client == nil {
+		dialer := &net.Dialer{Timeout: opts.Timeout, Control: opts.Control}
+
+		opts.Client = &http.Client{
+			// no proxy, the dialer has to see the address of the host itself
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	return &Verifier{
+		resolver: opts.Resolver,
+		client:   opts.Client,
+		checker:  opts.Checker,
+		timeout:  opts.Timeout,
+	}
+}
+
+// NewResolver returns a resolver asking the DNS server at addr,
+// the resolver of the system when addr is empty
+func NewResolver(addr string) *net.Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// Challenge tells the owner of the domain where to publish the token
+type Challenge struct {
+	DNSName  string `json:"dns_name"`
+	DNSValue string `json:"dns_value"`
+	HTTPURL  string `json:"http_url"`
+	HTTPBody string `json:"http_body"`
+}
+
+// ChallengeOf returns both challenges of the domain
+func ChallengeOf(d models.Domain) Challenge {
+	return Challenge{
+		DNSName:  RecordName + "." + d.Host,
+		DNSValue: RecordPrefix + d.Token,
+		HTTPURL:  challengeURL(d.Host),
+		HTTPBody: d.Token,
+	}
+}
+
+// NewToken returns a random token for a new domain
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Verify runs the challenge of the method against the domain.
+// Every reason the token was not found is wrapped in ErrNotVerified
+func (v *Verifier) Verify(ctx context.Context, method string, d models.Domain) error {
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	switch method {
+	case MethodDNS:
+		return v.verifyDNS(ctx, d)
+	case MethodHTTP:
+		return v.verifyHTTP(ctx, d)
+	default:
+		return ErrUnknownMethod
+	}
+}
+
+func (v *Verifier) verifyDNS(ctx context.Context, d models.Domain) error {
+	records, err := v.resolver.LookupTXT(ctx, RecordName+"."+d.Host)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotVerified, err)
+	}
+
+	for _, record := range records {
+		if strings.TrimSpace(record) == RecordPrefix+d.Token {
+			return nil
+		}
+	}
+
+	return ErrNotVerified
+}
+
+func (v *Verifier) verifyHTTP(ctx context.Context, d models.Domain) error {
+	u := challengeURL(d.Host)
+
+	if v.checker != nil {
+		if err := v.checker.Check(ctx, u); err != nil {
+			return fmt.Errorf("%w: %v", ErrNotVerified, err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotVerified, err)
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotVerified, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", ErrNotVerified, res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxFileSize))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotVerified, err)
+	}
+
+	if strings.TrimSpace(string(body)) != d.Token {
+		return ErrNotVerified
+	}
+
+	return nil
+}
+
+func challengeURL(host string) string {
+	return "http://" + host + FilePath
+}
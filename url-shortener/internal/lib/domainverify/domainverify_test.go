@@ -0,0 +1,206 @@
+package domainverify
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/urlpolicy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyDNS(t *testing.T) {
+	addr := serveDNS(t, map[string][]string{
+		"_shortener-challenge.go.acme.com.": {"v=spf1 -all", "shortener-verification=secret"},
+		"_shortener-challenge.acme.link.":   {"shortener-verification=other"},
+	})
+
+	v := New(Options{Resolver: NewResolver(addr)})
+
+	cases := []struct {
+		name string
+		host string
+		want error
+	}{
+		{name: "Published", host: "go.acme.com"},
+		{name: "Other token", host: "acme.link", want: ErrNotVerified},
+		{name: "No record", host: "example.com", want: ErrNotVerified},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := v.Verify(context.Background(), MethodDNS, models.Domain{Host: tc.host, Token: "secret"})
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+}
+
+func TestVerifyHTTP(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/shortener-verification.txt", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Host {
+		case "go.acme.com":
+			_, _ = w.Write([]byte("secret\n"))
+		case "moved.acme.com":
+			http.Redirect(w, r, "http://go.acme.com"+FilePath, http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	// every host is served by the test server
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, srv.Listener.Addr().String())
+			},
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	cases := []struct {
+		name    string
+		host    string
+		checker urlpolicy.Checker
+		want    error
+	}{
+		{name: "Published", host: "go.acme.com"},
+		{name: "Missing", host: "acme.link", want: ErrNotVerified},
+		{name: "Redirect", host: "moved.acme.com", want: ErrNotVerified},
+		{name: "Denied", host: "go.acme.com", checker: denyAll{}, want: ErrNotVerified},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := New(Options{Client: client, Checker: tc.checker})
+
+			err := v.Verify(context.Background(), MethodHTTP, models.Domain{Host: tc.host, Token: "secret"})
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+}
+
+func TestVerifyHTTPControl(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secret"))
+	}))
+	t.Cleanup(srv.Close)
+
+	host := srv.Listener.Addr().String()
+	denied := errors.New("denied")
+
+	cases := []struct {
+		name    string
+		control Control
+		want    error
+	}{
+		{name: "Allowed", control: func(string, string, syscall.RawConn) error { return nil }},
+		{name: "Denied at connect", control: func(string, string, syscall.RawConn) error { return denied }, want: ErrNotVerified},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := New(Options{Control: tc.control})
+
+			err := v.Verify(context.Background(), MethodHTTP, models.Domain{Host: host, Token: "secret"})
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+}
+
+func TestVerifyUnknownMethod(t *testing.T) {
+	err := New(Options{}).Verify(context.Background(), "email", models.Domain{Host: "go.acme.com"})
+	require.ErrorIs(t, err, ErrUnknownMethod)
+}
+
+type denyAll struct{}
+
+func (denyAll) Check(context.Context, string) error {
+	return errors.New("denied")
+}
+
+// serveDNS answers TXT queries over UDP with the records of the name,
+// other names get NXDOMAIN. It returns the address of the server
+func serveDNS(t *testing.T, records map[string][]string) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, peer, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			if res := dnsAnswer(buf[:n], records); res != nil {
+				_, _ = conn.WriteTo(res, peer)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func dnsAnswer(query []byte, records map[string][]string) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+
+	// the question is a sequence of labels followed by type and class
+	var labels []string
+	end := 12
+	for end < len(query) && query[end] != 0 {
+		size := int(query[end])
+		if end+1+size > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[end+1:end+1+size]))
+		end += 1 + size
+	}
+	end += 5
+	if end > len(query) {
+		return nil
+	}
+
+	answers := records[strings.ToLower(strings.Join(labels, "."))+"."]
+
+	res := make([]byte, 12, 512)
+	copy(res, query[:2])
+	binary.BigEndian.PutUint16(res[2:], 0x8180)
+	if answers == nil {
+		// NXDOMAIN
+		binary.BigEndian.PutUint16(res[2:], 0x8183)
+	}
+	binary.BigEndian.PutUint16(res[4:], 1)
+	binary.BigEndian.PutUint16(res[6:], uint16(len(answers)))
+	res = append(res, query[12:end]...)
+
+	for _, txt := range answers {
+		res = append(res, 0xc0, 12)
+		res = binary.BigEndian.AppendUint16(res, 16)
+		res = binary.BigEndian.AppendUint16(res, 1)
+		res = binary.BigEndian.AppendUint32(res, 60)
+		res = binary.BigEndian.AppendUint16(res, uint16(len(txt)+1))
+		res = append(res, byte(len(txt)))
+		res = append(res, txt...)
+	}
+
+	return res
+}
@@ -0,0 +1,122 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+var ErrOverlap = errors.New("networks overlap")
+
+type network struct {
+	prefix  netip.Prefix
+	country string
+}
+
+// DB resolves countries of IP addresses from a local CSV database
+// of "network,country" lines, e.g. "81.2.69.0/24,GB".
+// Networks must not overlap, which holds for GeoLite2 country blocks
+type DB struct {
+	networks []network
+}
+
+// Open loads the database from path, the first line may be a header
+func Open(path string) (*DB, error) {
+	const op = "lib.geoip.Open"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	db, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return db, nil
+}
+
+// Read parses the database from r
+func Read(r io.Reader) (*DB, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	db := &DB{}
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected network and country", line)
+		}
+
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				// header
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		country := strings.ToUpper(strings.TrimSpace(record[1]))
+		if country == "" {
+			continue
+		}
+
+		db.networks = append(db.networks, network{prefix: prefix.Masked(), country: country})
+	}
+
+	sort.Slice(db.networks, func(i, j int) bool {
+		return db.networks[i].prefix.Addr().Less(db.networks[j].prefix.Addr())
+	})
+
+	for i := 1; i < len(db.networks); i++ {
+		if db.networks[i-1].prefix.Overlaps(db.networks[i].prefix) {
+			return nil, fmt.Errorf("%w: %s and %s", ErrOverlap, db.networks[i-1].prefix, db.networks[i].prefix)
+		}
+	}
+
+	return db, nil
+}
+
+// Country returns the ISO code of the country of ip or an empty string when it is unknown.
+// A nil DB knows nothing, so the database stays optional
+func (db *DB) Country(ip string) string {
+	if db == nil {
+		return ""
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	// the last network starting at or before addr is the only one that may contain it
+	i := sort.Search(len(db.networks), func(i int) bool {
+		return addr.Less(db.networks[i].prefix.Addr())
+	})
+	if i == 0 {
+		return ""
+	}
+
+	if n := db.networks[i-1]; n.prefix.Contains(addr) {
+		return n.country
+	}
+
+	return ""
+}
@@ -0,0 +1,41 @@
+package geoip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountry(t *testing.T) {
+	db, err := Read(strings.NewReader("network,country\n81.2.69.0/24,gb\n# comment\n2.125.160.216/29,GB\n89.160.20.112/28,SE\n2001:218::/32,JP\n"))
+	require.NoError(t, err)
+
+	cases := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "81.2.69.142", want: "GB"},
+		{ip: "81.2.70.1", want: ""},
+		{ip: "89.160.20.127", want: "SE"},
+		{ip: "89.160.20.128", want: ""},
+		{ip: "::ffff:89.160.20.113", want: "SE"},
+		{ip: "2001:218:1::1", want: "JP"},
+		{ip: "1.1.1.1", want: ""},
+		{ip: "not an ip", want: ""},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.want, db.Country(tc.ip), tc.ip)
+	}
+}
+
+func TestNilDB(t *testing.T) {
+	var db *DB
+	require.Empty(t, db.Country("81.2.69.142"))
+}
+
+func TestOverlap(t *testing.T) {
+	_, err := Read(strings.NewReader("10.0.0.0/8,US\n10.1.0.0/16,CA\n"))
+	require.ErrorIs(t, err, ErrOverlap)
+}
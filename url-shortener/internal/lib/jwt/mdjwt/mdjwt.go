@@ -59,9 +59,7 @@ func (j *JWTConfig) JWTAuthMiddleware(next http.Handler) http.Handler {
 
 		userID := int(uidFloat)
 
-		ctx := context.WithValue(r.Context(), userIDKey, userID)
-
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), userID)))
 	})
 }
 
@@ -91,6 +89,11 @@ func ParseToken(tokenString, secretKey string) (jwt.MapClaims, error) {
 	return claims, nil
 }
 
+// WithUserID returns a copy of ctx carrying the authenticated user id
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
 func GetUserID(ctx context.Context) (int, bool) {
 	uid, ok := ctx.Value(userIDKey).(int)
 	return uid, ok
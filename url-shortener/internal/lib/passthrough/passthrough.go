@@ -0,0 +1,91 @@
+package passthrough
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Rest returns the path after the alias of the request in its escaped form.
+// chi matches the wildcard against the raw path when the request has one,
+// otherwise the decoded match is escaped here
+func Rest(r *http.Request) string {
+	rest := chi.URLParam(r, "*")
+	if r.URL.RawPath == "" {
+		rest = (&url.URL{Path: rest}).EscapedPath()
+	}
+
+	return rest
+}
+
+// Apply forwards the extra path and the query of the visit onto the target.
+// The escaped path, see Rest, is appended to the target path and cannot climb above it.
+// Query parameters set on the target win over the incoming ones with the same name
+func Apply(target string, rest string, query url.Values, forwardPath, forwardQuery bool) (string, error) {
+	if (!forwardPath || rest == "") && (!forwardQuery || len(query) == 0) {
+		return target, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	if forwardPath && rest != "" {
+		extra, err := clean(rest)
+		if err != nil {
+			return "", err
+		}
+
+		raw := strings.TrimSuffix(u.EscapedPath(), "/") + extra
+		if u.Path, err = url.PathUnescape(raw); err != nil {
+			return "", err
+		}
+		u.RawPath = raw
+	}
+
+	if forwardQuery && len(query) > 0 {
+		merged := u.Query()
+		for key, values := range query {
+			if _, ok := merged[key]; ok {
+				continue
+			}
+			merged[key] = values
+		}
+		u.RawQuery = merged.Encode()
+	}
+
+	return u.String(), nil
+}
+
+// clean drops empty, "." and ".." segments of the escaped path, so that it cannot
+// leave the target base even with encoded dots. Encoded slashes stay inside their segment
+func clean(rest string) (string, error) {
+	var segments []string
+
+	for _, segment := range strings.Split(rest, "/") {
+		name, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", err
+		}
+
+		switch name {
+		case "", ".":
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+		default:
+			segments = append(segments, segment)
+		}
+	}
+
+	extra := "/" + strings.Join(segments, "/")
+	if strings.HasSuffix(rest, "/") && len(segments) > 0 {
+		extra += "/"
+	}
+
+	return extra, nil
+}
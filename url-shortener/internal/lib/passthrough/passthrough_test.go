@@ -0,0 +1,100 @@
+package passthrough
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	cases := []struct {
+		name         string
+		target       string
+		rest         string
+		query        string
+		forwardPath  bool
+		forwardQuery bool
+		want         string
+	}{
+		{
+			name:   "Nothing forwarded",
+			target: "https://docs.example.com/v2",
+			rest:   "guide",
+			query:  "utm_source=x",
+			want:   "https://docs.example.com/v2",
+		},
+		{
+			name:         "Query merged",
+			target:       "https://example.com/landing?ref=short",
+			query:        "utm_source=x&utm_medium=email",
+			forwardQuery: true,
+			want:         "https://example.com/landing?ref=short&utm_medium=email&utm_source=x",
+		},
+		{
+			name:         "Target parameters win",
+			target:       "https://example.com/?utm_source=fixed",
+			query:        "utm_source=x",
+			forwardQuery: true,
+			want:         "https://example.com/?utm_source=fixed",
+		},
+		{
+			name:        "Path appended",
+			target:      "https://docs.example.com/v2/",
+			rest:        "docs/page",
+			forwardPath: true,
+			want:        "https://docs.example.com/v2/docs/page",
+		},
+		{
+			name:        "Trailing slash kept",
+			target:      "https://docs.example.com",
+			rest:        "guide/",
+			forwardPath: true,
+			want:        "https://docs.example.com/guide/",
+		},
+		{
+			name:        "Cannot climb above the base",
+			target:      "https://docs.example.com/v2",
+			rest:        "../../admin",
+			forwardPath: true,
+			want:        "https://docs.example.com/v2/admin",
+		},
+		{
+			name:        "Encoded dots cannot climb",
+			target:      "https://docs.example.com/v2",
+			rest:        "%2E%2E/%2e%2e/admin",
+			forwardPath: true,
+			want:        "https://docs.example.com/v2/admin",
+		},
+		{
+			name:        "Encoded slash kept",
+			target:      "https://files.example.com/get",
+			rest:        "a%2Fb/c%20d",
+			forwardPath: true,
+			want:        "https://files.example.com/get/a%2Fb/c%20d",
+		},
+		{
+			name:         "Path and query",
+			target:       "https://docs.example.com/v2?lang=en",
+			rest:         "search",
+			query:        "q=go",
+			forwardPath:  true,
+			forwardQuery: true,
+			want:         "https://docs.example.com/v2/search?lang=en&q=go",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			got, err := Apply(tc.target, tc.rest, query, tc.forwardPath, tc.forwardQuery)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
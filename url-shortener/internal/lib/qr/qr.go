@@ -0,0 +1,218 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// logoShare is the part of the code width covered by the logo,
+// small enough to be restored by the highest error correction level
+const logoShare = 5
+
+var (
+	ErrInvalidColor = errors.New("invalid color")
+	ErrInvalidLevel = errors.New("invalid error correction level")
+	ErrSizeTooSmall = errors.New("size is too small for the code")
+)
+
+type Options struct {
+	Size       int
+	Margin     int
+	Level      qrcode.RecoveryLevel
+	Foreground color.RGBA
+	Background color.RGBA
+	Logo       image.Image
+}
+
+// PNG renders the content as a square PNG image of opts.Size pixels
+func PNG(content string, opts Options) ([]byte, error) {
+	const op = "lib.qr.PNG"
+
+	bitmap, err := encode(content, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	modules := len(bitmap) + 2*opts.Margin
+	scale := opts.Size / modules
+	if scale < 1 {
+		return nil, fmt.Errorf("%s: %w", op, ErrSizeTooSmall)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Size, opts.Size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+
+	// the code is centered when the size is not divisible by the module count
+	offset := (opts.Size-scale*modules)/2 + opts.Margin*scale
+	fg := image.NewUniform(opts.Foreground)
+
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			rect := image.Rect(offset+x*scale, offset+y*scale, offset+(x+1)*scale, offset+(y+1)*scale)
+			draw.Draw(img, rect, fg, image.Point{}, draw.Src)
+		}
+	}
+
+	if opts.Logo != nil {
+		drawLogo(img, opts.Logo, opts.Background, len(bitmap)*scale)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SVG renders the content as a vector image with opts.Size as its width and height
+func SVG(content string, opts Options) ([]byte, error) {
+	const op = "lib.qr.SVG"
+
+	bitmap, err := encode(content, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	modules := len(bitmap) + 2*opts.Margin
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, modules, modules, Hex(opts.Background))
+
+	buf.WriteString(`<path fill="` + Hex(opts.Foreground) + `" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+	buf.WriteString(`"/>`)
+
+	if opts.Logo != nil {
+		var logo bytes.Buffer
+		if err := png.Encode(&logo, opts.Logo); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		side := float64(len(bitmap)) / logoShare
+		pos := float64(modules)/2 - side/2
+		fmt.Fprintf(&buf, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`,
+			pos-0.5, pos-0.5, side+1, side+1, Hex(opts.Background))
+		fmt.Fprintf(&buf, `<image x="%g" y="%g" width="%g" height="%g" href="data:image/png;base64,%s"/>`,
+			pos, pos, side, side, base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+
+	buf.WriteString(`</svg>`)
+
+	return buf.Bytes(), nil
+}
+
+// ParseLevel converts L, M, Q or H into the error correction level
+func ParseLevel(s string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return qrcode.Low, nil
+	case "M":
+		return qrcode.Medium, nil
+	case "Q":
+		return qrcode.High, nil
+	case "H":
+		return qrcode.Highest, nil
+	default:
+		return 0, ErrInvalidLevel
+	}
+}
+
+// ParseColor accepts colors as RGB or RRGGBB hex with an optional leading #
+func ParseColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, ErrInvalidColor
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidColor
+	}
+
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// Hex formats the color as #rrggbb
+func Hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// LoadLogo reads a PNG or JPEG image to be placed in the middle of codes
+func LoadLogo(path string) (image.Image, error) {
+	const op = "lib.qr.LoadLogo"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return img, nil
+}
+
+func encode(content string, opts Options) ([][]bool, error) {
+	code, err := qrcode.New(content, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+
+	return code.Bitmap(), nil
+}
+
+// drawLogo places the logo on a background square in the middle of the image
+func drawLogo(img *image.RGBA, logo image.Image, bg color.RGBA, codeSize int) {
+	side := codeSize / logoShare
+	if side < 1 {
+		return
+	}
+
+	center := img.Bounds().Dx() / 2
+	area := image.Rect(center-side/2, center-side/2, center-side/2+side, center-side/2+side)
+
+	draw.Draw(img, area.Inset(-side/10), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	// nearest neighbour scaling keeps the package free of extra dependencies
+	scaled := image.NewRGBA(image.Rect(0, 0, side, side))
+	src := logo.Bounds()
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			scaled.Set(x, y, logo.At(src.Min.X+x*src.Dx()/side, src.Min.Y+y*src.Dy()/side))
+		}
+	}
+
+	draw.Draw(img, area, scaled, image.Point{}, draw.Over)
+}
@@ -0,0 +1,40 @@
+package qr
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{in: "#336699", want: color.RGBA{R: 0x33, G: 0x66, B: 0x99, A: 0xff}},
+		{in: "fff", want: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{in: "blue", wantErr: true},
+		{in: "#12345g", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseColor(tt.in)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidColor)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPNGSizeTooSmall(t *testing.T) {
+	_, err := PNG("https://sho.rt/promo", Options{Size: 10, Margin: 4})
+	require.ErrorIs(t, err, ErrSizeTooSmall)
+}
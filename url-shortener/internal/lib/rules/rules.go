@@ -0,0 +1,197 @@
+package rules
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+)
+
+var (
+	ErrNoConditions = errors.New("rule must have at least one condition")
+	ErrTimeWindow   = errors.New("time_from and time_to must be set together")
+)
+
+const clock = "15:04"
+
+// CountryResolver finds the country of an IP address, an empty string means unknown
+//
+//go:generate mockery --name=CountryResolver --dir=. --output=./mocks --filename=country_resolver_mock.go --outpkg=mocks
+type CountryResolver interface {
+	Country(ip string) string
+}
+
+// Visitor is what the conditions of rules are matched against
+type Visitor struct {
+	Platform string
+	// Language is the most preferred language from Accept-Language
+	Language string
+	Country  string
+	Time     time.Time
+}
+
+// NewVisitor describes the client of r, ip is its address without port.
+// geo may be nil when no database is configured
+func NewVisitor(r *http.Request, ip string, geo CountryResolver, now time.Time) Visitor {
+	v := Visitor{
+		Platform: Platform(r.UserAgent()),
+		Language: PreferredLanguage(r.Header.Get("Accept-Language")),
+		Time:     now,
+	}
+
+	if geo != nil {
+		v.Country = geo.Country(ip)
+	}
+
+	return v
+}
+
+// Match returns the first rule whose conditions all hold for the visitor
+func Match(rules []models.Rule, v Visitor) (models.Rule, bool) {
+	for _, rule := range rules {
+		if matches(rule, v) {
+			return rule, true
+		}
+	}
+
+	return models.Rule{}, false
+}
+
+// Validate checks the rule can ever be told apart from the default target
+func Validate(rule models.Rule) error {
+	if (rule.TimeFrom == "") != (rule.TimeTo == "") {
+		return ErrTimeWindow
+	}
+
+	if rule.Platform == "" && rule.Language == "" && rule.Country == "" && rule.TimeFrom == "" {
+		return ErrNoConditions
+	}
+
+	return nil
+}
+
+func matches(rule models.Rule, v Visitor) bool {
+	if rule.Platform != "" && rule.Platform != v.Platform {
+		return false
+	}
+
+	if rule.Language != "" && !languageMatches(rule.Language, v.Language) {
+		return false
+	}
+
+	if rule.Country != "" && !strings.EqualFold(rule.Country, v.Country) {
+		return false
+	}
+
+	if rule.TimeFrom != "" && !inWindow(rule, v.Time) {
+		return false
+	}
+
+	return true
+}
+
+// Platform classifies the User-Agent, an empty one matches no platform
+func Platform(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case ua == "":
+		return ""
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return models.PlatformIOS
+	case strings.Contains(ua, "android"):
+		return models.PlatformAndroid
+	default:
+		return models.PlatformDesktop
+	}
+}
+
+// PreferredLanguage returns the tag with the highest weight in the Accept-Language header
+func PreferredLanguage(header string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+
+	if len(tags) == 0 {
+		return ""
+	}
+
+	// stable keeps the header order for equal weights
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	return tags[0].tag
+}
+
+// languageMatches compares tags case-insensitively, "de" also matches "de-AT"
+func languageMatches(want, got string) bool {
+	if len(got) < len(want) || !strings.EqualFold(got[:len(want)], want) {
+		return false
+	}
+
+	return len(got) == len(want) || got[len(want)] == '-'
+}
+
+// inWindow reports whether t falls into [TimeFrom, TimeTo) in the timezone of the rule.
+// A window ending before it starts passes midnight, equal ends mean the whole day
+func inWindow(rule models.Rule, t time.Time) bool {
+	loc := time.UTC
+	if rule.Timezone != "" {
+		l, err := time.LoadLocation(rule.Timezone)
+		if err != nil {
+			return false
+		}
+		loc = l
+	}
+
+	from, err := time.Parse(clock, rule.TimeFrom)
+	if err != nil {
+		return false
+	}
+	to, err := time.Parse(clock, rule.TimeTo)
+	if err != nil {
+		return false
+	}
+
+	t = t.In(loc)
+	now := t.Hour()*60 + t.Minute()
+	start := from.Hour()*60 + from.Minute()
+	end := to.Hour()*60 + to.Minute()
+
+	switch {
+	case start == end:
+		return true
+	case start < end:
+		return now >= start && now < end
+	default:
+		return now >= start || now < end
+	}
+}
@@ -0,0 +1,116 @@
+package rules
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	uaIPhone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	uaAndroid = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36"
+	uaDesktop = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+)
+
+func TestPlatform(t *testing.T) {
+	require.Equal(t, models.PlatformIOS, Platform(uaIPhone))
+	require.Equal(t, models.PlatformAndroid, Platform(uaAndroid))
+	require.Equal(t, models.PlatformDesktop, Platform(uaDesktop))
+	require.Empty(t, Platform(""))
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "de-AT", want: "de-AT"},
+		{header: "en;q=0.5, fr-CH, fr;q=0.9", want: "fr-CH"},
+		{header: "*, ru;q=0.8", want: "ru"},
+		{header: "en;q=0, es;q=0.1", want: "es"},
+		{header: "en, de", want: "en"},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.want, PreferredLanguage(tc.header), tc.header)
+	}
+}
+
+type countries map[string]string
+
+func (c countries) Country(ip string) string {
+	return c[ip]
+}
+
+func TestMatch(t *testing.T) {
+	rules := []models.Rule{
+		{ID: 1, Platform: models.PlatformIOS, URL: "https://apps.apple.com/app/id1"},
+		{ID: 2, Platform: models.PlatformAndroid, URL: "https://play.google.com/store/apps/details?id=app"},
+		{ID: 3, Language: "de", Country: "AT", URL: "https://example.at"},
+		{ID: 4, TimeFrom: "22:00", TimeTo: "06:00", Timezone: "Europe/Berlin", URL: "https://example.com/night"},
+	}
+
+	noon := time.Date(2026, 1, 10, 11, 0, 0, 0, time.UTC)   // 12:00 in Berlin
+	night := time.Date(2026, 1, 10, 23, 30, 0, 0, time.UTC) // 00:30 in Berlin
+
+	geo := countries{"1.2.3.4": "AT", "5.6.7.8": "DE"}
+
+	cases := []struct {
+		name     string
+		ua       string
+		language string
+		ip       string
+		now      time.Time
+		want     int64
+	}{
+		{name: "iPhone", ua: uaIPhone, now: noon, want: 1},
+		{name: "Android", ua: uaAndroid, now: noon, want: 2},
+		{name: "Austrian German", ua: uaDesktop, language: "de-AT,en;q=0.5", ip: "1.2.3.4", now: noon, want: 3},
+		{name: "German outside Austria", ua: uaDesktop, language: "de", ip: "5.6.7.8", now: noon},
+		{name: "English in Austria", ua: uaDesktop, language: "en,de;q=0.9", ip: "1.2.3.4", now: noon},
+		{name: "Night window", ua: uaDesktop, now: night, want: 4},
+		{name: "First rule wins", ua: uaIPhone, now: night, want: 1},
+		{name: "No match", ua: uaDesktop, now: noon},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("GET", "/promo", nil)
+			r.Header.Set("User-Agent", tc.ua)
+			r.Header.Set("Accept-Language", tc.language)
+
+			rule, ok := Match(rules, NewVisitor(r, tc.ip, geo, tc.now))
+			require.Equal(t, tc.want != 0, ok)
+			require.Equal(t, tc.want, rule.ID)
+		})
+	}
+}
+
+func TestInWindow(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 10, h, m, 0, 0, time.UTC) }
+
+	day := models.Rule{TimeFrom: "09:00", TimeTo: "18:00"}
+	require.True(t, inWindow(day, at(9, 0)))
+	require.True(t, inWindow(day, at(17, 59)))
+	require.False(t, inWindow(day, at(18, 0)))
+	require.False(t, inWindow(day, at(8, 59)))
+
+	allDay := models.Rule{TimeFrom: "00:00", TimeTo: "00:00"}
+	require.True(t, inWindow(allDay, at(13, 37)))
+
+	badZone := models.Rule{TimeFrom: "09:00", TimeTo: "18:00", Timezone: "Mars/Olympus"}
+	require.False(t, inWindow(badZone, at(12, 0)))
+}
+
+func TestValidate(t *testing.T) {
+	require.ErrorIs(t, Validate(models.Rule{URL: "https://a.com"}), ErrNoConditions)
+	require.ErrorIs(t, Validate(models.Rule{TimeFrom: "09:00", URL: "https://a.com"}), ErrTimeWindow)
+	require.NoError(t, Validate(models.Rule{Country: "DE", URL: "https://a.com"}))
+}
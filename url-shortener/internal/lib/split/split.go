@@ -0,0 +1,64 @@
+package split
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+)
+
+// CookieMaxAge keeps visitors on their variant for the length of a typical experiment
+const CookieMaxAge = 90 * 24 * time.Hour
+
+// CookieName is per link, so one visitor can take part in several splits
+func CookieName(linkID int64) string {
+	return "ab_" + strconv.FormatInt(linkID, 10)
+}
+
+// Pick returns the variant of the visitor of r. The variant from the cookie is kept
+// while it still exists, otherwise a new one is chosen by weight and assigned is true
+func Pick(r *http.Request, linkID int64, variants []models.Variant) (variant models.Variant, assigned bool) {
+	if c, err := r.Cookie(CookieName(linkID)); err == nil {
+		for _, v := range variants {
+			if v.Name == c.Value {
+				return v, false
+			}
+		}
+	}
+
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return models.Variant{}, false
+	}
+
+	return choose(variants, rand.IntN(total)), true
+}
+
+// Cookie remembers the variant of the visitor for the link served at path
+func Cookie(linkID int64, path string, variant models.Variant) *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName(linkID),
+		Value:    variant.Name,
+		Path:     path,
+		MaxAge:   int(CookieMaxAge / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// choose maps n from [0, total weight) to the variant owning that slice of the range
+func choose(variants []models.Variant, n int) models.Variant {
+	for _, v := range variants {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+
+	return variants[len(variants)-1]
+}
@@ -0,0 +1,58 @@
+package split
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/stretchr/testify/require"
+)
+
+var variants = []models.Variant{
+	{Name: "a", URL: "https://example.com/a", Weight: 70},
+	{Name: "b", URL: "https://example.com/b", Weight: 30},
+}
+
+func TestChoose(t *testing.T) {
+	require.Equal(t, "a", choose(variants, 0).Name)
+	require.Equal(t, "a", choose(variants, 69).Name)
+	require.Equal(t, "b", choose(variants, 70).Name)
+	require.Equal(t, "b", choose(variants, 99).Name)
+}
+
+func TestPickWeights(t *testing.T) {
+	counts := map[string]int{}
+
+	for i := 0; i < 10000; i++ {
+		v, assigned := Pick(httptest.NewRequest(http.MethodGet, "/promo", nil), 1, variants)
+		require.True(t, assigned)
+		counts[v.Name]++
+	}
+
+	// 70/30 with a generous margin for randomness
+	require.InDelta(t, 7000, counts["a"], 400)
+	require.InDelta(t, 3000, counts["b"], 400)
+}
+
+func TestPickSticky(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/promo", nil)
+	r.AddCookie(Cookie(1, "/promo", variants[1]))
+
+	for i := 0; i < 100; i++ {
+		v, assigned := Pick(r, 1, variants)
+		require.False(t, assigned)
+		require.Equal(t, "b", v.Name)
+	}
+
+	// the cookie of another link does not count
+	_, assigned := Pick(r, 2, variants)
+	require.True(t, assigned)
+
+	// a removed variant is reassigned
+	r = httptest.NewRequest(http.MethodGet, "/promo", nil)
+	r.AddCookie(&http.Cookie{Name: CookieName(1), Value: "c"})
+
+	_, assigned = Pick(r, 1, variants)
+	require.True(t, assigned)
+}
@@ -0,0 +1,386 @@
+package urlpolicy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/common/kafka"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+)
+
+var (
+	ErrInvalidURL    = errors.New("URL is invalid")
+	ErrScheme        = errors.New("URL scheme is not allowed")
+	ErrDeniedHost    = errors.New("URL host is not allowed")
+	ErrDeniedAddress = errors.New("URL points to a private address")
+	ErrSelfLink      = errors.New("URL points to the shortener itself")
+	ErrUnresolvable  = errors.New("URL host cannot be resolved")
+	ErrBlocked       = errors.New("URL domain is blocked")
+	ErrCheckFailed   = errors.New("URL could not be checked")
+)
+
+// DomainLookup finds the verified custom domain serving a host,
+// storage.ErrDomainNotFound is returned for hosts of no domain
+type DomainLookup interface {
+	Domain(host string) (models.Domain, error)
+}
+
+// Checker rejects URLs that must not be shortened, redirected to or requested, like
+// private addresses. Policy is the Checker of the service
+//
+//go:generate mockery --name=Checker --dir=. --output=./mocks --filename=checker_mock.go --outpkg=mocks
+type Checker interface {
+	Check(ctx context.Context, rawURL string) error
+}
+
+type Options struct {
+	Schemes     []string
+	DeniedHosts []string
+	DeniedCIDRs []string
+	// SelfHosts are the hosts the shortener is served from
+	SelfHosts []string
+	// Domains finds custom domains serving short links, they are self hosts too
+	Domains DomainLookup
+	// ResolveHosts checks the addresses of domain names against DeniedCIDRs as well
+	ResolveHosts  bool
+	BlocklistPath string
+}
+
+// Policy decides whether a target URL may be shortened
+type Policy struct {
+	schemes  map[string]struct{}
+	denied   []string
+	prefixes []netip.Prefix
+	self     []string
+	domains  DomainLookup
+	resolve  bool
+	resolver *net.Resolver
+
+	blocklistPath string
+
+	mu        sync.RWMutex
+	blocklist []string
+	modTime   time.Time
+}
+
+func New(opts Options) (*Policy, error) {
+	const op = "lib.urlpolicy.New"
+
+	if len(opts.Schemes) == 0 {
+		opts.Schemes = []string{"http", "https"}
+	}
+
+	p := &Policy{
+		schemes:       make(map[string]struct{}, len(opts.Schemes)),
+		denied:        normalizeHosts(opts.DeniedHosts),
+		self:          normalizeHosts(opts.SelfHosts),
+		domains:       opts.Domains,
+		resolve:       opts.ResolveHosts,
+		resolver:      net.DefaultResolver,
+		blocklistPath: opts.BlocklistPath,
+	}
+
+	for _, s := range opts.Schemes {
+		p.schemes[strings.ToLower(s)] = struct{}{}
+	}
+
+	for _, c := range opts.DeniedCIDRs {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(c))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		p.prefixes = append(p.prefixes, prefix)
+	}
+
+	if p.blocklistPath != "" {
+		if _, err := p.Reload(); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return p, nil
+}
+
+// Check returns the reason why the target must not be shortened, or nil
+func (p *Policy) Check(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ErrInvalidURL
+	}
+
+	if _, ok := p.schemes[strings.ToLower(u.Scheme)]; !ok {
+		return ErrScheme
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return ErrInvalidURL
+	}
+
+	if matchHost(host, p.self) {
+		return ErrSelfLink
+	}
+
+	if p.domains != nil {
+		_, err := p.domains.Domain(host)
+		switch {
+		case err == nil:
+			return ErrSelfLink
+		case !errors.Is(err, storage.ErrDomainNotFound):
+			return ErrCheckFailed
+		}
+	}
+
+	if matchHost(host, p.denied) {
+		return ErrDeniedHost
+	}
+
+	p.mu.RLock()
+	blocked := matchHost(host, p.blocklist)
+	p.mu.RUnlock()
+
+	if blocked {
+		return ErrBlocked
+	}
+
+	if addr, ok := parseAddr(host); ok {
+		if p.deniedAddr(addr) {
+			return ErrDeniedAddress
+		}
+		return nil
+	}
+
+	if p.resolve && len(p.prefixes) > 0 {
+		addrs, err := p.resolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			// the host may resolve to a private address later, it is not taken on trust
+			return ErrUnresolvable
+		}
+		for _, addr := range addrs {
+			if p.deniedAddr(addr) {
+				return ErrDeniedAddress
+			}
+		}
+	}
+
+	return nil
+}
+
+// Control refuses connections to denied addresses, it is the Control hook of a net.Dialer.
+// It sees the address actually dialed, so a host resolving differently after Check
+// cannot lead the connection to a private network
+func (p *Policy) Control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if p.deniedAddr(addr) {
+		return ErrDeniedAddress
+	}
+
+	return nil
+}
+
+// Watch reloads the blocklist file when it changes, until ctx is done
+func (p *Policy) Watch(ctx context.Context, log *slog.Logger, interval time.Duration) {
+	if p.blocklistPath == "" || interval <= 0 {
+		return
+	}
+
+	log = log.With(slog.String("component", "urlpolicy"), slog.String("path", p.blocklistPath))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reloaded, err := p.Reload()
+				if err != nil {
+					log.Error("failed to reload url blocklist", sl.Err(err))
+					continue
+				}
+				if reloaded {
+					log.Info("url blocklist reloaded")
+				}
+			}
+		}
+	}()
+}
+
+// Reload reads the blocklist file if it was modified since the last read
+func (p *Policy) Reload() (bool, error) {
+	info, err := os.Stat(p.blocklistPath)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.RLock()
+	unchanged := info.ModTime().Equal(p.modTime)
+	p.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	hosts, err := readHosts(p.blocklistPath)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	p.blocklist = hosts
+	p.modTime = info.ModTime()
+	p.mu.Unlock()
+
+	return true, nil
+}
+
+// Event builds the audit record of a target rejected while handling r
+func Event(r *http.Request, userID int64, rawURL string, reason error, action string) map[string]interface{} {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return map[string]interface{}{
+		"type":      kafka.EventLinkBlocked,
+		"timestamp": time.Now().UTC(),
+		"user_id":   userID,
+		"url":       rawURL,
+		"reason":    reason.Error(),
+		"action":    action,
+		"ip":        ip,
+	}
+}
+
+func (p *Policy) deniedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseAddr also understands the forms of IPv4 that inet_aton accepts, like 2130706433,
+// 0x7f000001, 127.1 or 0177.0.0.1, browsers and many clients open them as 127.0.0.1
+func parseAddr(host string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return addr, true
+	}
+
+	return parseInetAton(host)
+}
+
+// parseInetAton reads one to four parts separated by dots, each decimal, octal with
+// a leading 0 or hex with 0x. The last part fills all the bytes left after the others
+func parseInetAton(host string) (netip.Addr, bool) {
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+
+	var ip uint64
+
+	for i, part := range parts {
+		if part == "" {
+			return netip.Addr{}, false
+		}
+
+		n, err := strconv.ParseUint(part, 0, 32)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+
+		if i < len(parts)-1 {
+			if n > 0xff {
+				return netip.Addr{}, false
+			}
+			ip |= n << (8 * (3 - i))
+			continue
+		}
+
+		if n >= 1<<(8*(4-i)) {
+			return netip.Addr{}, false
+		}
+		ip |= n
+	}
+
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+// matchHost reports whether host is one of hosts or their subdomain
+func matchHost(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeHosts(hosts []string) []string {
+	out := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		h = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(h)), ".")
+		if h != "" {
+			out = append(out, h)
+		}
+	}
+
+	return out
+}
+
+// readHosts loads one domain per line, lines starting with # are comments
+func readHosts(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var hosts []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hosts = append(hosts, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return normalizeHosts(hosts), nil
+}
@@ -0,0 +1,141 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	p, err := New(Options{
+		DeniedHosts: []string{"localhost"},
+		DeniedCIDRs: []string{"127.0.0.0/8", "10.0.0.0/8", "169.254.0.0/16", "::1/128"},
+		SelfHosts:   []string{"sho.rt"},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{name: "Public", url: "https://example.com/page"},
+		{name: "Uppercase scheme", url: "HTTPS://example.com"},
+		{name: "Javascript", url: "javascript:alert(1)", want: ErrScheme},
+		{name: "File", url: "file:///etc/passwd", want: ErrScheme},
+		{name: "Data", url: "data:text/html,<script>alert(1)</script>", want: ErrScheme},
+		{name: "Localhost", url: "http://localhost:8080/admin", want: ErrDeniedHost},
+		{name: "Localhost subdomain", url: "http://api.localhost/", want: ErrDeniedHost},
+		{name: "Loopback", url: "http://127.0.0.1/", want: ErrDeniedAddress},
+		{name: "Metadata", url: "http://169.254.169.254/latest/meta-data", want: ErrDeniedAddress},
+		{name: "Private", url: "http://10.1.2.3:9000", want: ErrDeniedAddress},
+		{name: "IPv6 loopback", url: "http://[::1]/", want: ErrDeniedAddress},
+		{name: "IPv4 mapped IPv6", url: "http://[::ffff:127.0.0.1]/", want: ErrDeniedAddress},
+		{name: "Integer IPv4", url: "http://2130706433/", want: ErrDeniedAddress},
+		{name: "Hex IPv4", url: "http://0x7f000001/", want: ErrDeniedAddress},
+		{name: "Short IPv4", url: "http://127.1/", want: ErrDeniedAddress},
+		{name: "Three part IPv4", url: "http://10.1.515/", want: ErrDeniedAddress},
+		{name: "Octal IPv4", url: "http://0177.0.0.1/", want: ErrDeniedAddress},
+		{name: "Hex parts IPv4", url: "http://0x7f.0x0.0x0.0x1/", want: ErrDeniedAddress},
+		{name: "Octal private IPv4", url: "http://012.0.0.1/", want: ErrDeniedAddress},
+		{name: "Self", url: "https://sho.rt/abc", want: ErrSelfLink},
+		{name: "Self with trailing dot", url: "https://SHO.RT./abc", want: ErrSelfLink},
+		{name: "No host", url: "http:///path", want: ErrInvalidURL},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.ErrorIs(t, p.Check(context.Background(), tc.url), tc.want)
+		})
+	}
+}
+
+func TestCheckUnresolvable(t *testing.T) {
+	p, err := New(Options{DeniedCIDRs: []string{"127.0.0.0/8"}, ResolveHosts: true})
+	require.NoError(t, err)
+
+	p.resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("no dns")
+		},
+	}
+
+	require.ErrorIs(t, p.Check(context.Background(), "https://rebind.example/"), ErrUnresolvable)
+}
+
+type domainLookup map[string]error
+
+func (l domainLookup) Domain(host string) (models.Domain, error) {
+	err, ok := l[host]
+	if !ok {
+		return models.Domain{}, storage.ErrDomainNotFound
+	}
+
+	return models.Domain{Host: host}, err
+}
+
+func TestCheckCustomDomains(t *testing.T) {
+	p, err := New(Options{
+		SelfHosts: []string{"sho.rt"},
+		Domains:   domainLookup{"go.acme.com": nil, "down.acme.com": errors.New("connection refused")},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, p.Check(context.Background(), "https://acme.com/spring"))
+	require.ErrorIs(t, p.Check(context.Background(), "https://GO.acme.com./promo"), ErrSelfLink)
+	require.ErrorIs(t, p.Check(context.Background(), "https://down.acme.com/promo"), ErrCheckFailed)
+}
+
+func TestControl(t *testing.T) {
+	p, err := New(Options{DeniedCIDRs: []string{"127.0.0.0/8", "::1/128"}})
+	require.NoError(t, err)
+
+	require.NoError(t, p.Control("tcp4", "93.184.216.34:80", nil))
+	require.ErrorIs(t, p.Control("tcp4", "127.0.0.1:80", nil), ErrDeniedAddress)
+	require.ErrorIs(t, p.Control("tcp6", "[::1]:80", nil), ErrDeniedAddress)
+	require.ErrorIs(t, p.Control("tcp6", "[::ffff:127.0.0.1]:80", nil), ErrDeniedAddress)
+}
+
+func TestBlocklistReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# comment\nevil.com\n"), 0o644))
+
+	p, err := New(Options{BlocklistPath: path})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, p.Check(context.Background(), "https://login.evil.com"), ErrBlocked)
+	require.NoError(t, p.Check(context.Background(), "https://notevil.com"))
+	require.NoError(t, p.Check(context.Background(), "https://phish.net"))
+
+	reloaded, err := p.Reload()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	require.NoError(t, os.WriteFile(path, []byte("phish.net\n"), 0o644))
+	// mtime resolution of some filesystems is coarse
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+
+	reloaded, err = p.Reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	require.ErrorIs(t, p.Check(context.Background(), "https://phish.net/x"), ErrBlocked)
+	require.NoError(t, p.Check(context.Background(), "https://evil.com"))
+}
+
+func TestNewInvalidCIDR(t *testing.T) {
+	_, err := New(Options{DeniedCIDRs: []string{"10.0.0.0/33"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,405 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/lib/logger/sl"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/storage"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix       = "link:"
+	domainKeyPrefix = "domain:"
+	// notFound is cached for aliases missing in the database
+	notFound = "-"
+)
+
+// Storage is a read-through Redis cache in front of the postgres storage.
+// Reads of links go to Redis first, writes go to postgres and drop the cached entry
+type Storage struct {
+	*storage.Storage
+	rdb         *redis.Client
+	log         *slog.Logger
+	ttl         time.Duration
+	notFoundTTL time.Duration
+}
+
+func New(
+	log *slog.Logger,
+	db *storage.Storage,
+	rdb *redis.Client,
+	ttl time.Duration,
+	notFoundTTL time.Duration,
+) *Storage {
+	return &Storage{
+		Storage:     db,
+		rdb:         rdb,
+		log:         log.With(slog.String("component", "storage/cache")),
+		ttl:         ttl,
+		notFoundTTL: notFoundTTL,
+	}
+}
+
+// GetLink returns the link from Redis or loads it from postgres and caches the result.
+// Redis failures are logged and the request falls back to postgres
+func (s *Storage) GetLink(alias string) (models.Link, error) {
+	ctx := context.Background()
+	key := keyPrefix + alias
+
+	cached, err := s.rdb.Get(ctx, key).Result()
+	switch {
+	case err == nil && cached == notFound:
+		return models.Link{}, storage.ErrURLNotFound
+	case err == nil:
+		var link models.Link
+		if err := json.Unmarshal([]byte(cached), &link); err == nil {
+			return link, nil
+		}
+		s.log.Error("broken cache entry", slog.String("alias", alias))
+	case !errors.Is(err, redis.Nil):
+		s.log.Error("failed to read cache", sl.Err(err))
+	}
+
+	link, err := s.Storage.GetLink(alias)
+	switch {
+	case errors.Is(err, storage.ErrURLNotFound):
+		s.set(ctx, key, notFound, s.notFoundTTL)
+		return models.Link{}, err
+	case err != nil:
+		return models.Link{}, err
+	}
+
+	b, err := json.Marshal(link)
+	if err != nil {
+		s.log.Error("failed to encode cache entry", sl.Err(err))
+		return link, nil
+	}
+	s.set(ctx, key, string(b), s.ttl)
+
+	return link, nil
+}
+
+// ConsumeClick counts a click of the link and drops the cached link once no clicks are left,
+// HEAD requests are not counted and decide from the cached counter that the link is exhausted
+func (s *Storage) ConsumeClick(link models.Link) error {
+	left, err := s.Storage.ConsumeClick(link.ID)
+	if err == nil && left == 0 || errors.Is(err, storage.ErrLinkExhausted) {
+		s.Invalidate(link.Ref())
+	}
+
+	return err
+}
+
+func (s *Storage) SaveURL(link models.Link) (int64, error) {
+	id, err := s.Storage.SaveURL(link)
+	if err == nil {
+		// the alias may be cached as missing
+		s.Invalidate(link.Ref())
+	}
+
+	return id, err
+}
+
+func (s *Storage) SaveURLs(links []models.Link, atomic bool) ([]int64, []error, error) {
+	ids, errs, err := s.Storage.SaveURLs(links, atomic)
+	if err == nil {
+		for i, link := range links {
+			if errs[i] == nil {
+				s.Invalidate(link.Ref())
+			}
+		}
+	}
+
+	return ids, errs, err
+}
+
+func (s *Storage) UpdateURL(alias string, newURL string, changedBy int64) (int64, string, error) {
+	id, oldURL, err := s.Storage.UpdateURL(alias, newURL, changedBy)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return id, oldURL, err
+}
+
+func (s *Storage) DeleteURL(alias string) error {
+	err := s.Storage.DeleteURL(alias)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) SetRedirectCode(alias string, code int) error {
+	err := s.Storage.SetRedirectCode(alias, code)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) SetForwarding(alias string, query, path bool) error {
+	err := s.Storage.SetForwarding(alias, query, path)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) SetPreview(alias, title, description string, always bool) error {
+	err := s.Storage.SetPreview(alias, title, description, always)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) SetDeepLink(alias string, deepLink *models.DeepLink) error {
+	err := s.Storage.SetDeepLink(alias, deepLink)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) UpdatePage(alias, title, description string, page models.Page) error {
+	err := s.Storage.UpdatePage(alias, title, description, page)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) SetPaused(alias string, paused bool) (int64, bool, error) {
+	id, changed, err := s.Storage.SetPaused(alias, paused)
+	if changed {
+		s.Invalidate(alias)
+	}
+
+	return id, changed, err
+}
+
+func (s *Storage) RestoreURL(alias string) error {
+	err := s.Storage.RestoreURL(alias)
+	if err == nil {
+		// the alias is cached as missing while the link is in the trash
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) AddRule(alias string, rule models.Rule) (int64, error) {
+	id, err := s.Storage.AddRule(alias, rule)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return id, err
+}
+
+func (s *Storage) UpdateRule(alias string, rule models.Rule) error {
+	err := s.Storage.UpdateRule(alias, rule)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) DeleteRule(alias string, ruleID int64) error {
+	err := s.Storage.DeleteRule(alias, ruleID)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) SetVariants(alias string, variants []models.Variant) error {
+	err := s.Storage.SetVariants(alias, variants)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) SetLinkTags(alias string, tags []string) error {
+	err := s.Storage.SetLinkTags(alias, tags)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+func (s *Storage) SetLinkFolder(alias string, folderID *int64) error {
+	err := s.Storage.SetLinkFolder(alias, folderID)
+	if err == nil {
+		s.Invalidate(alias)
+	}
+
+	return err
+}
+
+// RenameTag drops cached links of the tag, their clicks are reported with tag names
+func (s *Storage) RenameTag(userID, tagID int64, name string) error {
+	aliases, err := s.Storage.TagAliases(tagID)
+	if err != nil {
+		return err
+	}
+
+	err = s.Storage.RenameTag(userID, tagID, name)
+	if err == nil {
+		for _, alias := range aliases {
+			s.Invalidate(alias)
+		}
+	}
+
+	return err
+}
+
+func (s *Storage) DeleteTag(userID, tagID int64) error {
+	aliases, err := s.Storage.TagAliases(tagID)
+	if err != nil {
+		return err
+	}
+
+	err = s.Storage.DeleteTag(userID, tagID)
+	if err == nil {
+		for _, alias := range aliases {
+			s.Invalidate(alias)
+		}
+	}
+
+	return err
+}
+
+// DeleteFolder drops cached links of the folder, the database moves them out of it
+func (s *Storage) DeleteFolder(userID, folderID int64) error {
+	aliases, err := s.Storage.FolderAliases(folderID)
+	if err != nil {
+		return err
+	}
+
+	err = s.Storage.DeleteFolder(userID, folderID)
+	if err == nil {
+		for _, alias := range aliases {
+			s.Invalidate(alias)
+		}
+	}
+
+	return err
+}
+
+func (s *Storage) PurgeExpired(now time.Time, archive bool, limit int) ([]models.Link, error) {
+	links, err := s.Storage.PurgeExpired(now, archive, limit)
+	for _, link := range links {
+		s.Invalidate(link.Ref())
+	}
+
+	return links, err
+}
+
+// Domain returns the verified domain of the host from Redis or loads it from postgres,
+// hosts without a verified domain are cached as missing
+func (s *Storage) Domain(host string) (models.Domain, error) {
+	ctx := context.Background()
+	key := domainKeyPrefix + host
+
+	cached, err := s.rdb.Get(ctx, key).Result()
+	switch {
+	case err == nil && cached == notFound:
+		return models.Domain{}, storage.ErrDomainNotFound
+	case err == nil:
+		var d models.Domain
+		if err := json.Unmarshal([]byte(cached), &d); err == nil {
+			return d, nil
+		}
+		s.log.Error("broken cache entry", slog.String("host", host))
+	case !errors.Is(err, redis.Nil):
+		s.log.Error("failed to read cache", sl.Err(err))
+	}
+
+	d, err := s.Storage.Domain(host)
+	switch {
+	case errors.Is(err, storage.ErrDomainNotFound):
+		s.set(ctx, key, notFound, s.notFoundTTL)
+		return models.Domain{}, err
+	case err != nil:
+		return models.Domain{}, err
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		s.log.Error("failed to encode cache entry", sl.Err(err))
+		return d, nil
+	}
+	s.set(ctx, key, string(b), s.ttl)
+
+	return d, nil
+}
+
+func (s *Storage) VerifyDomain(userID, domainID int64, at time.Time) (models.Domain, error) {
+	d, err := s.Storage.VerifyDomain(userID, domainID, at)
+	if err == nil {
+		// the host is cached as missing until it is verified
+		s.invalidateDomain(d.Host)
+	}
+
+	return d, err
+}
+
+func (s *Storage) SetDomainNotFoundURL(userID, domainID int64, url string) (models.Domain, error) {
+	d, err := s.Storage.SetDomainNotFoundURL(userID, domainID, url)
+	if err == nil {
+		s.invalidateDomain(d.Host)
+	}
+
+	return d, err
+}
+
+func (s *Storage) DeleteDomain(userID, domainID int64) (models.Domain, error) {
+	d, err := s.Storage.DeleteDomain(userID, domainID)
+	if err == nil {
+		s.invalidateDomain(d.Host)
+	}
+
+	return d, err
+}
+
+func (s *Storage) invalidateDomain(host string) {
+	if err := s.rdb.Del(context.Background(), domainKeyPrefix+host).Err(); err != nil {
+		s.log.Error("failed to invalidate cache", slog.String("host", host), sl.Err(err))
+	}
+}
+
+// Invalidate drops the cached entry of the link with the alias, see models.LinkRef
+func (s *Storage) Invalidate(alias string) {
+	if err := s.rdb.Del(context.Background(), keyPrefix+alias).Err(); err != nil {
+		s.log.Error("failed to invalidate cache", slog.String("alias", alias), sl.Err(err))
+	}
+}
+
+func (s *Storage) set(ctx context.Context, key, value string, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	if err := s.rdb.Set(ctx, key, value, ttl).Err(); err != nil {
+		s.log.Error("failed to write cache", sl.Err(err))
+	}
+}
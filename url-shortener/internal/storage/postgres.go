@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 	"github.com/lostmyescape/link-shortener/common/logger/sl"
 	"github.com/lostmyescape/link-shortener/url-shortener/internal/config"
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/domain/models"
 )
 
 type Storage struct {
@@ -67,63 +69,1900 @@ func connect(cfg *config.Config) (*Storage, error) {
 	return &Storage{DB: db}, nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
+// insertLink keeps the id reserved with NextLinkID and takes the next one otherwise
+const insertLink = `INSERT INTO url(id, url, alias, domain, user_id, expires_at, max_clicks, password_hash, folder_id, redirect_code,
+		forward_query, forward_path, not_before, title, description, always_preview, app_uri, ios_store_url, android_store_url,
+		page_theme)
+	VALUES (COALESCE(NULLIF($1::bigint, 0), nextval(pg_get_serial_sequence('url', 'id'))), $2, $3, $4, $5, $6, $7, $8, $9,
+		NULLIF($10::smallint, 0), $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+	RETURNING id`
+
+// SaveURL inserts the link together with its tags and page items,
+// the folder and the links listed on the page must belong to the owner of the link
+func (s *Storage) SaveURL(link models.Link) (int64, error) {
 	const op = "storage.postgres.SaveUrl"
 
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if link.FolderID != nil {
+		if err := checkFolder(tx, link.UserID, *link.FolderID); err != nil {
+			return 0, err
+		}
+	}
+
+	var id int64
+
+	appURI, iosURL, androidURL := deepLinkArgs(link.DeepLink)
+
+	err = tx.QueryRow(
+		insertLink,
+		link.ID,
+		link.URL,
+		link.Alias,
+		link.Domain,
+		link.UserID,
+		link.ExpiresAt,
+		link.MaxClicks,
+		link.PasswordHash,
+		link.FolderID,
+		link.RedirectCode,
+		link.ForwardQuery,
+		link.ForwardPath,
+		link.NotBefore,
+		link.Title,
+		link.Description,
+		link.AlwaysPreview,
+		appURI,
+		iosURL,
+		androidURL,
+		pageTheme(link.Page),
+	).Scan(&id)
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return 0, uniqueErr
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := setTags(tx, link.UserID, id, link.Tags); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if link.Page != nil {
+		if err := setPageItems(tx, link.UserID, id, link.Page.Items); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// NextLinkID reserves the id of a link that is going to be saved,
+// so that the alias can be derived from it before the insert
+func (s *Storage) NextLinkID() (int64, error) {
+	const op = "storage.postgres.NextLinkID"
+
 	var id int64
-	query := `INSERT INTO url(url, alias) VALUES ($1, $2) RETURNING id`
+	if err := s.DB.QueryRow(`SELECT nextval(pg_get_serial_sequence('url', 'id'))`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// SaveURLs inserts the links in one transaction and returns ids and errors per link.
+// In atomic mode the first conflict rolls back the whole batch and ErrBatchRejected is returned,
+// otherwise every link is saved under its own savepoint so conflicts only skip that link.
+// Links filed into a folder of another user fail with ErrFolderNotFound like in SaveURL
+func (s *Storage) SaveURLs(links []models.Link, atomic bool) ([]int64, []error, error) {
+	const op = "storage.postgres.SaveURLs"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
 
-	err := s.DB.QueryRow(query, urlToSave, alias).Scan(&id)
+	stmt, err := tx.Prepare(insertLink)
 	if err != nil {
-		var pqErr *pq.Error
-		if errors.As(err, &pqErr) {
-			if pqErr.Code == "23505" {
-				if pqErr.Constraint == "url_url_key" {
-					return 0, ErrURLExists
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, len(links))
+	errs := make([]error, len(links))
+
+	// links of a batch usually share folders, each one is checked once
+	folders := make(map[int64]error)
+
+	for i, link := range links {
+		if link.FolderID != nil {
+			folderErr, ok := folders[*link.FolderID]
+			if !ok {
+				folderErr = checkFolder(tx, link.UserID, *link.FolderID)
+				if folderErr != nil && !errors.Is(folderErr, ErrFolderNotFound) {
+					return nil, nil, fmt.Errorf("%s: %w", op, folderErr)
+				}
+				folders[*link.FolderID] = folderErr
+			}
+
+			if folderErr != nil {
+				errs[i] = folderErr
+				if atomic {
+					return make([]int64, len(links)), errs, ErrBatchRejected
 				}
-				if pqErr.Constraint == "url_alias_key" {
-					return 0, ErrAliasExists
+				continue
+			}
+		}
+
+		if !atomic {
+			if _, err := tx.Exec(`SAVEPOINT batch_item`); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		appURI, iosURL, androidURL := deepLinkArgs(link.DeepLink)
+
+		err := stmt.QueryRow(
+			link.ID,
+			link.URL,
+			link.Alias,
+			link.Domain,
+			link.UserID,
+			link.ExpiresAt,
+			link.MaxClicks,
+			link.PasswordHash,
+			link.FolderID,
+			link.RedirectCode,
+			link.ForwardQuery,
+			link.ForwardPath,
+			link.NotBefore,
+			link.Title,
+			link.Description,
+			link.AlwaysPreview,
+			appURI,
+			iosURL,
+			androidURL,
+			pageTheme(link.Page),
+		).Scan(&ids[i])
+		if err == nil {
+			err = setTags(tx, link.UserID, ids[i], link.Tags)
+		}
+
+		uniqueErr := uniqueViolation(err)
+		switch {
+		case err == nil:
+			if !atomic {
+				if _, err := tx.Exec(`RELEASE SAVEPOINT batch_item`); err != nil {
+					return nil, nil, fmt.Errorf("%s: %w", op, err)
 				}
 			}
+		case uniqueErr == nil:
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		case atomic:
+			errs[i] = uniqueErr
+			return make([]int64, len(links)), errs, ErrBatchRejected
+		default:
+			errs[i] = uniqueErr
+			if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT batch_item`); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ids, errs, nil
+}
+
+// uniqueViolation maps unique constraint errors to storage errors
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		switch pqErr.Constraint {
+		// the same target is unique per owner only
+		case "url_user_url_key":
+			return ErrURLExists
+		// aliases are unique per domain
+		case "url_ref_key":
+			return ErrAliasExists
+		case "tags_user_name_key":
+			return ErrTagExists
+		case "folders_user_name_key":
+			return ErrFolderExists
+		case "utm_templates_user_name_key":
+			return ErrTemplateExists
+		case "domains_user_host_key":
+			return ErrDomainExists
+		case "domains_verified_host_key":
+			return ErrDomainTaken
 		}
+	}
+
+	return nil
+}
+
+// GetLink returns the link stored under the alias
+func (s *Storage) GetLink(alias string) (models.Link, error) {
+	const op = "storage.postgres.GetLink"
+
+	link, err := scanLink(s.DB.QueryRow(`SELECT `+linkColumns+` FROM url WHERE ref = $1 AND deleted_at IS NULL`, alias))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Link{}, ErrURLNotFound
+	}
+	if err != nil {
+		return models.Link{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// rules and variants are loaded here so that the cached link has everything the redirect needs
+	link.Rules, err = s.LinkRules(alias)
+	if err != nil {
+		return models.Link{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(link.Rules) == 0 {
+		link.Rules = nil
+	}
+
+	link.Variants, err = s.LinkVariants(alias)
+	if err != nil {
+		return models.Link{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(link.Variants) == 0 {
+		link.Variants = nil
+	}
+
+	return link, nil
+}
+
+// ConsumeClick counts a click of the link with limited clicks and returns the clicks left.
+// It returns ErrLinkExhausted when the limit has already been reached
+func (s *Storage) ConsumeClick(linkID int64) (int64, error) {
+	const op = "storage.postgres.ConsumeClick"
+
+	var left int64
+
+	err := s.DB.QueryRow(
+		`UPDATE url SET clicks = clicks + 1 WHERE id = $1 AND (max_clicks IS NULL OR clicks < max_clicks)
+		RETURNING COALESCE(max_clicks - clicks, 0)`,
+		linkID,
+	).Scan(&left)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrLinkExhausted
+	}
+	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return id, nil
+	return left, nil
+}
+
+// archiveColumns are copied to url_archive when an expired link is purged
+const archiveColumns = `id, alias, url, user_id, created_at, expires_at, max_clicks, clicks`
+
+// PurgeExpired removes up to limit links that are past their expiration date
+// or out of clicks. With archive set the rows are moved to url_archive
+func (s *Storage) PurgeExpired(now time.Time, archive bool, limit int) ([]models.Link, error) {
+	const op = "storage.postgres.PurgeExpired"
+
+	query := `
+		WITH expired AS (
+			DELETE FROM url
+			WHERE id IN (
+				SELECT id FROM url
+				WHERE deleted_at IS NULL
+					AND (expires_at <= $1 OR (max_clicks IS NOT NULL AND clicks >= max_clicks))
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + linkColumns + `
+		)`
+
+	if archive {
+		query += `, archived AS (
+			INSERT INTO url_archive (` + archiveColumns + `)
+			SELECT ` + archiveColumns + ` FROM expired
+			ON CONFLICT (id) DO NOTHING
+		)`
+	}
+
+	query += ` SELECT * FROM expired`
+
+	rows, err := s.DB.Query(query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var links []models.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// UserLinkByURL returns the link of the user on the domain pointing to the url
+func (s *Storage) UserLinkByURL(userID int64, domain, url string) (models.Link, error) {
+	const op = "storage.postgres.UserLinkByURL"
+
+	link, err := scanLink(s.DB.QueryRow(
+		`SELECT `+linkColumns+` FROM url WHERE user_id = $1 AND domain = $2 AND url = $3 AND deleted_at IS NULL`,
+		userID, domain, url,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Link{}, ErrURLNotFound
+	}
+	if err != nil {
+		return models.Link{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return link, nil
+}
+
+// LinkPasswordHash returns bcrypt hash of the link password
+func (s *Storage) LinkPasswordHash(linkID int64) ([]byte, error) {
+	const op = "storage.postgres.LinkPasswordHash"
+
+	var hash []byte
+
+	err := s.DB.QueryRow(`SELECT password_hash FROM url WHERE id = $1`, linkID).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrURLNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hash, nil
+}
+
+// URLOwner returns id of the user who created the alias, links in the trash are not found
+func (s *Storage) URLOwner(alias string) (int64, error) {
+	const op = "storage.postgres.URLOwner"
+
+	var userID int64
+
+	err := s.DB.QueryRow(`SELECT user_id FROM url WHERE ref = $1 AND deleted_at IS NULL`, alias).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAliasNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// UpdateURL points the alias to a new target and keeps the previous one
+// in the revision history. It returns the link id and the previous target
+func (s *Storage) UpdateURL(alias string, newURL string, changedBy int64) (int64, string, error) {
+	const op = "storage.postgres.UpdateURL"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		id     int64
+		oldURL string
+	)
+
+	err = tx.QueryRow(`SELECT id, url FROM url WHERE ref = $1 AND deleted_at IS NULL FOR UPDATE`, alias).Scan(&id, &oldURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", ErrAliasNotFound
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO url_revisions(url_id, url, changed_by) VALUES ($1, $2, $3)`,
+		id, oldURL, changedBy,
+	)
+	if err != nil {
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = tx.Exec(`UPDATE url SET url = $1 WHERE id = $2`, newURL, id); err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return 0, "", uniqueErr
+		}
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, oldURL, nil
+}
+
+// URLRevisions returns previous targets of the alias, newest first
+func (s *Storage) URLRevisions(alias string) ([]models.Revision, error) {
+	const op = "storage.postgres.URLRevisions"
+
+	rows, err := s.DB.Query(`
+		SELECT r.id, r.url, r.changed_by, r.created_at
+		FROM url_revisions r
+		JOIN url u ON u.id = r.url_id
+		WHERE u.ref = $1
+		ORDER BY r.id DESC`, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	revisions := make([]models.Revision, 0)
+	for rows.Next() {
+		var rev models.Revision
+		if err := rows.Scan(&rev.ID, &rev.URL, &rev.ChangedBy, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
 }
 
-func (s *Storage) GetUrl(alias string) (string, error) {
-	const op = "storage.postgres.GetUrl"
+// URLRevision returns a single revision of the alias
+func (s *Storage) URLRevision(alias string, revisionID int64) (models.Revision, error) {
+	const op = "storage.postgres.URLRevision"
 
-	var urlString string
+	var rev models.Revision
 
-	err := s.DB.QueryRow(`SELECT url FROM url WHERE alias = $1`, alias).Scan(&urlString)
+	err := s.DB.QueryRow(`
+		SELECT r.id, r.url, r.changed_by, r.created_at
+		FROM url_revisions r
+		JOIN url u ON u.id = r.url_id
+		WHERE u.ref = $1 AND r.id = $2`, alias, revisionID,
+	).Scan(&rev.ID, &rev.URL, &rev.ChangedBy, &rev.CreatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
-		return "", ErrURLNotFound
+		return models.Revision{}, ErrRevisionNotFound
 	}
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return models.Revision{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return urlString, nil
+	return rev, nil
 }
 
+// DeleteURL moves the link to the trash. The alias stays reserved
+// until the link is restored or purged by the janitor
 func (s *Storage) DeleteURL(alias string) error {
 	const op = "storage.postgres.DeleteURL"
 
-	result, err := s.DB.Exec(`DELETE FROM url WHERE alias = $1`, alias)
+	result, err := s.DB.Exec(`UPDATE url SET deleted_at = now() WHERE ref = $1 AND deleted_at IS NULL`, alias)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return affected(op, result, ErrAliasNotFound)
+}
+
+// TrashedURLs returns links of the user in the trash, recently deleted first
+func (s *Storage) TrashedURLs(userID int64) ([]models.Link, error) {
+	const op = "storage.postgres.TrashedURLs"
+
+	rows, err := s.DB.Query(
+		`SELECT `+linkColumns+` FROM url
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	links := make([]models.Link, 0)
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// TrashedURLOwner returns id of the user who created the alias in the trash
+func (s *Storage) TrashedURLOwner(alias string) (int64, error) {
+	const op = "storage.postgres.TrashedURLOwner"
+
+	var userID int64
+
+	err := s.DB.QueryRow(`SELECT user_id FROM url WHERE ref = $1 AND deleted_at IS NOT NULL`, alias).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAliasNotFound
+	}
 	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// RestoreURL takes the link out of the trash. It returns ErrURLExists
+// when the owner has shortened the same target again in the meantime
+func (s *Storage) RestoreURL(alias string) error {
+	const op = "storage.postgres.RestoreURL"
+
+	result, err := s.DB.Exec(`UPDATE url SET deleted_at = NULL WHERE ref = $1 AND deleted_at IS NOT NULL`, alias)
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return uniqueErr
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return ErrAliasNotFound
+	return affected(op, result, ErrAliasNotFound)
+}
+
+// PurgeDeleted removes up to limit links that were moved to the trash before the given time
+func (s *Storage) PurgeDeleted(before time.Time, limit int) ([]models.Link, error) {
+	const op = "storage.postgres.PurgeDeleted"
+
+	rows, err := s.DB.Query(`
+		DELETE FROM url
+		WHERE id IN (
+			SELECT id FROM url
+			WHERE deleted_at <= $1
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+linkColumns, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer rows.Close()
 
-	return nil
+	var links []models.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// ListURLs returns links of the user ordered by creation time,
+// starting right after filter.After when it is set
+func (s *Storage) ListURLs(filter models.LinkFilter) ([]models.Link, error) {
+	const op = "storage.postgres.ListURLs"
+
+	var sb strings.Builder
+	args := []interface{}{filter.UserID}
+
+	sb.WriteString(`SELECT ` + linkColumns + ` FROM url WHERE user_id = $1 AND deleted_at IS NULL`)
+
+	if filter.AliasPrefix != "" {
+		args = append(args, escapeLike(filter.AliasPrefix)+"%")
+		fmt.Fprintf(&sb, ` AND alias LIKE $%d ESCAPE '\'`, len(args))
+	}
+
+	if filter.Domain != "" {
+		args = append(args, filter.Domain)
+		fmt.Fprintf(&sb, ` AND domain = $%d`, len(args))
+	}
+
+	if filter.TargetHost != "" {
+		host := strings.ToLower(filter.TargetHost)
+		args = append(args, host, "%."+escapeLike(host))
+		fmt.Fprintf(&sb, ` AND (%[1]s = $%[2]d OR %[1]s LIKE $%[3]d ESCAPE '\')`, hostExpr, len(args)-1, len(args))
+	}
+
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		fmt.Fprintf(&sb, ` AND EXISTS (
+			SELECT 1 FROM url_tags ut JOIN tags t ON t.id = ut.tag_id
+			WHERE ut.url_id = url.id AND t.name = $%d)`, len(args))
+	}
+
+	if filter.FolderID != nil {
+		args = append(args, *filter.FolderID)
+		fmt.Fprintf(&sb, ` AND folder_id = $%d`, len(args))
+	}
+
+	cmp, order := ">", "ASC"
+	if filter.Desc {
+		cmp, order = "<", "DESC"
+	}
+
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt, filter.After.ID)
+		fmt.Fprintf(&sb, ` AND (created_at, id) %s ($%d, $%d)`, cmp, len(args)-1, len(args))
+	}
+
+	args = append(args, filter.Limit)
+	fmt.Fprintf(&sb, ` ORDER BY created_at %[1]s, id %[1]s LIMIT $%[2]d`, order, len(args))
+
+	rows, err := s.DB.Query(sb.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	links := make([]models.Link, 0, filter.Limit)
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// ruleColumns are selected by every query that returns models.Rule
+const ruleColumns = `r.id, r.position, r.platform, r.language, r.country, r.time_from, r.time_to, r.timezone, r.url`
+
+// LinkRules returns redirect rules of the alias in evaluation order
+func (s *Storage) LinkRules(alias string) ([]models.Rule, error) {
+	const op = "storage.postgres.LinkRules"
+
+	rows, err := s.DB.Query(`
+		SELECT `+ruleColumns+`
+		FROM url_rules r
+		JOIN url u ON u.id = r.url_id
+		WHERE u.ref = $1
+		ORDER BY r.position, r.id`, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	rules := make([]models.Rule, 0)
+	for rows.Next() {
+		var rule models.Rule
+		err := rows.Scan(
+			&rule.ID,
+			&rule.Position,
+			&rule.Platform,
+			&rule.Language,
+			&rule.Country,
+			&rule.TimeFrom,
+			&rule.TimeTo,
+			&rule.Timezone,
+			&rule.URL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rules, nil
+}
+
+// AddRule appends the rule to the alias, a zero position puts it after the existing ones
+func (s *Storage) AddRule(alias string, rule models.Rule) (int64, error) {
+	const op = "storage.postgres.AddRule"
+
+	var id int64
+
+	err := s.DB.QueryRow(`
+		INSERT INTO url_rules(url_id, position, platform, language, country, time_from, time_to, timezone, url)
+		SELECT u.id,
+			COALESCE(NULLIF($2, 0), (SELECT COALESCE(MAX(position), 0) + 1 FROM url_rules WHERE url_id = u.id)),
+			$3, $4, $5, $6, $7, $8, $9
+		FROM url u
+		WHERE u.ref = $1
+		RETURNING id`,
+		alias, rule.Position, rule.Platform, rule.Language, rule.Country,
+		rule.TimeFrom, rule.TimeTo, rule.Timezone, rule.URL,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAliasNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UpdateRule replaces the conditions and target of the rule, a zero position keeps the current one
+func (s *Storage) UpdateRule(alias string, rule models.Rule) error {
+	const op = "storage.postgres.UpdateRule"
+
+	result, err := s.DB.Exec(`
+		UPDATE url_rules r
+		SET position = COALESCE(NULLIF($3, 0), r.position),
+			platform = $4, language = $5, country = $6,
+			time_from = $7, time_to = $8, timezone = $9, url = $10
+		FROM url u
+		WHERE u.id = r.url_id AND u.ref = $1 AND r.id = $2`,
+		alias, rule.ID, rule.Position, rule.Platform, rule.Language, rule.Country,
+		rule.TimeFrom, rule.TimeTo, rule.Timezone, rule.URL,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+
+	return nil
+}
+
+// DeleteRule removes the rule of the alias
+func (s *Storage) DeleteRule(alias string, ruleID int64) error {
+	const op = "storage.postgres.DeleteRule"
+
+	result, err := s.DB.Exec(`
+		DELETE FROM url_rules r
+		USING url u
+		WHERE u.id = r.url_id AND u.ref = $1 AND r.id = $2`, alias, ruleID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+
+	return nil
+}
+
+// LinkVariants returns split destinations of the alias
+func (s *Storage) LinkVariants(alias string) ([]models.Variant, error) {
+	const op = "storage.postgres.LinkVariants"
+
+	rows, err := s.DB.Query(`
+		SELECT v.id, v.name, v.url, v.weight
+		FROM url_variants v
+		JOIN url u ON u.id = v.url_id
+		WHERE u.ref = $1
+		ORDER BY v.id`, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	variants := make([]models.Variant, 0)
+	for rows.Next() {
+		var v models.Variant
+		if err := rows.Scan(&v.ID, &v.Name, &v.URL, &v.Weight); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		variants = append(variants, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variants, nil
+}
+
+// SetVariants replaces split destinations of the alias, an empty list turns the split off
+func (s *Storage) SetVariants(alias string, variants []models.Variant) error {
+	const op = "storage.postgres.SetVariants"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int64
+
+	err = tx.QueryRow(`SELECT id FROM url WHERE ref = $1 FOR UPDATE`, alias).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAliasNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM url_variants WHERE url_id = $1`, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, v := range variants {
+		_, err := tx.Exec(
+			`INSERT INTO url_variants(url_id, name, url, weight) VALUES ($1, $2, $3, $4)`,
+			id, v.Name, v.URL, v.Weight,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetLinkTags replaces tags of the alias, missing tags are created for the owner of the link
+func (s *Storage) SetLinkTags(alias string, tags []string) error {
+	const op = "storage.postgres.SetLinkTags"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id, userID int64
+
+	err = tx.QueryRow(`SELECT id, user_id FROM url WHERE ref = $1 FOR UPDATE`, alias).Scan(&id, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAliasNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := setTags(tx, userID, id, tags); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetLinkFolder moves the alias to the folder of its owner, nil takes it out of any folder
+func (s *Storage) SetLinkFolder(alias string, folderID *int64) error {
+	const op = "storage.postgres.SetLinkFolder"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id, userID int64
+
+	err = tx.QueryRow(`SELECT id, user_id FROM url WHERE ref = $1 FOR UPDATE`, alias).Scan(&id, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAliasNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if folderID != nil {
+		if err := checkFolder(tx, userID, *folderID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE url SET folder_id = $1 WHERE id = $2`, folderID, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetRedirectCode changes the status code of the alias redirects, 0 restores the default
+func (s *Storage) SetRedirectCode(alias string, code int) error {
+	const op = "storage.postgres.SetRedirectCode"
+
+	result, err := s.DB.Exec(
+		`UPDATE url SET redirect_code = NULLIF($1::smallint, 0) WHERE ref = $2 AND deleted_at IS NULL`,
+		code, alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected(op, result, ErrAliasNotFound)
+}
+
+// SetPreview changes what the preview page of the alias shows and whether every visit gets it
+func (s *Storage) SetPreview(alias, title, description string, always bool) error {
+	const op = "storage.postgres.SetPreview"
+
+	result, err := s.DB.Exec(
+		`UPDATE url SET title = $1, description = $2, always_preview = $3 WHERE ref = $4 AND deleted_at IS NULL`,
+		title, description, always, alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected(op, result, ErrAliasNotFound)
+}
+
+// SetDeepLink changes the app and store targets of the alias, nil turns the deep link off
+func (s *Storage) SetDeepLink(alias string, deepLink *models.DeepLink) error {
+	const op = "storage.postgres.SetDeepLink"
+
+	appURI, iosURL, androidURL := deepLinkArgs(deepLink)
+
+	result, err := s.DB.Exec(
+		`UPDATE url SET app_uri = $1, ios_store_url = $2, android_store_url = $3 WHERE ref = $4 AND deleted_at IS NULL`,
+		appURI, iosURL, androidURL, alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected(op, result, ErrAliasNotFound)
+}
+
+// deepLinkArgs maps the deep link to its columns, missing values are stored as NULL
+func deepLinkArgs(deepLink *models.DeepLink) (appURI, iosURL, androidURL sql.NullString) {
+	if deepLink == nil {
+		return
+	}
+
+	return sql.NullString{String: deepLink.AppURI, Valid: true},
+		sql.NullString{String: deepLink.IOSStoreURL, Valid: deepLink.IOSStoreURL != ""},
+		sql.NullString{String: deepLink.AndroidStoreURL, Valid: deepLink.AndroidStoreURL != ""}
+}
+
+// Page returns the link-in-bio page of the alias with all of its items
+func (s *Storage) Page(alias string) (models.Link, error) {
+	const op = "storage.postgres.Page"
+
+	link, err := scanLink(s.DB.QueryRow(
+		`SELECT `+linkColumns+` FROM url WHERE ref = $1 AND deleted_at IS NULL AND page_theme IS NOT NULL`,
+		alias,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Link{}, ErrPageNotFound
+	}
+	if err != nil {
+		return models.Link{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	link.Page.Items, err = s.pageItems(link.ID, false)
+	if err != nil {
+		return models.Link{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return link, nil
+}
+
+// PageItems returns the items of the page that can be followed now.
+// They are not cached with the page, so changes of the listed links show up at once
+func (s *Storage) PageItems(pageID int64) ([]models.PageItem, error) {
+	const op = "storage.postgres.PageItems"
+
+	items, err := s.pageItems(pageID, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return items, nil
+}
+
+func (s *Storage) pageItems(pageID int64, live bool) ([]models.PageItem, error) {
+	query := `
+		SELECT u.alias, pi.title, pi.position FROM page_items pi
+		JOIN url u ON u.id = pi.url_id
+		WHERE pi.page_id = $1 AND u.deleted_at IS NULL`
+	if live {
+		query += ` AND NOT u.paused
+			AND (u.expires_at IS NULL OR u.expires_at > now())
+			AND (u.not_before IS NULL OR u.not_before <= now())
+			AND (u.max_clicks IS NULL OR u.clicks < u.max_clicks)`
+	}
+	query += ` ORDER BY pi.position`
+
+	rows, err := s.DB.Query(query, pageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.PageItem, 0)
+	for rows.Next() {
+		var item models.PageItem
+		if err := rows.Scan(&item.Alias, &item.Title, &item.Position); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// UpdatePage changes title, description and theme of the page and replaces its items
+func (s *Storage) UpdatePage(alias, title, description string, page models.Page) error {
+	const op = "storage.postgres.UpdatePage"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id, userID int64
+
+	err = tx.QueryRow(`
+		UPDATE url SET title = $1, description = $2, page_theme = $3
+		WHERE ref = $4 AND deleted_at IS NULL AND page_theme IS NOT NULL
+		RETURNING id, user_id`,
+		title, description, page.Theme, alias,
+	).Scan(&id, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPageNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := setPageItems(tx, userID, id, page.Items); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// setPageItems replaces items of the page, every item must be a link of the user
+// on the domain of the page and not a page itself
+func setPageItems(tx *sql.Tx, userID, pageID int64, items []models.PageItem) error {
+	const op = "storage.postgres.setPageItems"
+
+	if _, err := tx.Exec(`DELETE FROM page_items WHERE page_id = $1`, pageID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, item := range items {
+		result, err := tx.Exec(`
+			INSERT INTO page_items(page_id, url_id, title, position)
+			SELECT $1, id, $2, $3 FROM url
+			WHERE alias = $4 AND user_id = $5 AND deleted_at IS NULL AND page_theme IS NULL
+				AND domain = (SELECT domain FROM url WHERE id = $1)`,
+			pageID, item.Title, item.Position, item.Alias, userID,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := affected(op, result, ErrPageItemNotFound); err != nil {
+			return fmt.Errorf("%w: %s", err, item.Alias)
+		}
+	}
+
+	return nil
+}
+
+// pageTheme maps the page to its column, links that are not pages store NULL
+func pageTheme(page *models.Page) sql.NullString {
+	if page == nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: page.Theme, Valid: true}
+}
+
+// SetPaused pauses or resumes the alias. changed is false when the link already was in the state
+func (s *Storage) SetPaused(alias string, paused bool) (id int64, changed bool, err error) {
+	const op = "storage.postgres.SetPaused"
+
+	err = s.DB.QueryRow(`
+		UPDATE url SET paused = $1
+		FROM (SELECT id, paused FROM url WHERE ref = $2 AND deleted_at IS NULL FOR UPDATE) old
+		WHERE url.id = old.id
+		RETURNING url.id, old.paused <> $1`,
+		paused, alias,
+	).Scan(&id, &changed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, ErrAliasNotFound
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, changed, nil
+}
+
+// ActivateScheduled marks up to limit links whose launch time has come as activated
+// and returns them, each link is returned once
+func (s *Storage) ActivateScheduled(now time.Time, limit int) ([]models.Link, error) {
+	const op = "storage.postgres.ActivateScheduled"
+
+	rows, err := s.DB.Query(`
+		UPDATE url SET activated_at = $1
+		WHERE id IN (
+			SELECT id FROM url
+			WHERE not_before <= $1 AND activated_at IS NULL AND deleted_at IS NULL
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+linkColumns, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var links []models.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// SetForwarding changes whether the query and the path after the alias are forwarded to the target
+func (s *Storage) SetForwarding(alias string, query, path bool) error {
+	const op = "storage.postgres.SetForwarding"
+
+	result, err := s.DB.Exec(
+		`UPDATE url SET forward_query = $1, forward_path = $2 WHERE ref = $3 AND deleted_at IS NULL`,
+		query, path, alias,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected(op, result, ErrAliasNotFound)
+}
+
+// setTags replaces tags of the link, creating the missing ones for the user
+func setTags(tx *sql.Tx, userID, linkID int64, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM url_tags WHERE url_id = $1`, linkID); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO tags(user_id, name) SELECT $1, unnest($2::text[])
+		ON CONFLICT (user_id, name) DO NOTHING`, userID, pq.Array(tags))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO url_tags(url_id, tag_id)
+		SELECT $1, id FROM tags WHERE user_id = $2 AND name = ANY($3)`, linkID, userID, pq.Array(tags))
+
+	return err
+}
+
+// checkFolder returns ErrFolderNotFound unless the folder belongs to the user
+func checkFolder(tx *sql.Tx, userID, folderID int64) error {
+	var exists bool
+
+	err := tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM folders WHERE id = $1 AND user_id = $2)`, folderID, userID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("storage.postgres.checkFolder: %w", err)
+	}
+
+	if !exists {
+		return ErrFolderNotFound
+	}
+
+	return nil
+}
+
+// Tags returns tags of the user ordered by name
+func (s *Storage) Tags(userID int64) ([]models.Tag, error) {
+	const op = "storage.postgres.Tags"
+
+	rows, err := s.DB.Query(`SELECT id, name, created_at FROM tags WHERE user_id = $1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	tags := make([]models.Tag, 0)
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+func (s *Storage) CreateTag(userID int64, name string) (models.Tag, error) {
+	const op = "storage.postgres.CreateTag"
+
+	tag := models.Tag{Name: name}
+
+	err := s.DB.QueryRow(
+		`INSERT INTO tags(user_id, name) VALUES ($1, $2) RETURNING id, created_at`, userID, name,
+	).Scan(&tag.ID, &tag.CreatedAt)
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return models.Tag{}, uniqueErr
+		}
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag, nil
+}
+
+func (s *Storage) RenameTag(userID, tagID int64, name string) error {
+	const op = "storage.postgres.RenameTag"
+
+	result, err := s.DB.Exec(`UPDATE tags SET name = $1 WHERE id = $2 AND user_id = $3`, name, tagID, userID)
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return uniqueErr
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected(op, result, ErrTagNotFound)
+}
+
+// DeleteTag removes the tag from the user and all of the links
+func (s *Storage) DeleteTag(userID, tagID int64) error {
+	const op = "storage.postgres.DeleteTag"
+
+	result, err := s.DB.Exec(`DELETE FROM tags WHERE id = $1 AND user_id = $2`, tagID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected(op, result, ErrTagNotFound)
+}
+
+// TagAliases returns refs of the links having the tag
+func (s *Storage) TagAliases(tagID int64) ([]string, error) {
+	const op = "storage.postgres.TagAliases"
+
+	rows, err := s.DB.Query(`
+		SELECT u.ref FROM url_tags ut JOIN url u ON u.id = ut.url_id
+		WHERE ut.tag_id = $1`, tagID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var aliases []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		aliases = append(aliases, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return aliases, nil
+}
+
+// Folders returns folders of the user ordered by name
+func (s *Storage) Folders(userID int64) ([]models.Folder, error) {
+	const op = "storage.postgres.Folders"
+
+	rows, err := s.DB.Query(`SELECT id, name, created_at FROM folders WHERE user_id = $1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	folders := make([]models.Folder, 0)
+	for rows.Next() {
+		var folder models.Folder
+		if err := rows.Scan(&folder.ID, &folder.Name, &folder.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		folders = append(folders, folder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return folders, nil
+}
+
+func (s *Storage) CreateFolder(userID int64, name string) (models.Folder, error) {
+	const op = "storage.postgres.CreateFolder"
+
+	folder := models.Folder{Name: name}
+
+	err := s.DB.QueryRow(
+		`INSERT INTO folders(user_id, name) VALUES ($1, $2) RETURNING id, created_at`, userID, name,
+	).Scan(&folder.ID, &folder.CreatedAt)
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return models.Folder{}, uniqueErr
+		}
+		return models.Folder{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return folder, nil
+}
+
+func (s *Storage) RenameFolder(userID, folderID int64, name string) error {
+	const op = "storage.postgres.RenameFolder"
+
+	result, err := s.DB.Exec(`UPDATE folders SET name = $1 WHERE id = $2 AND user_id = $3`, name, folderID, userID)
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return uniqueErr
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected(op, result, ErrFolderNotFound)
+}
+
+// DeleteFolder removes the folder, its links stay without a folder
+func (s *Storage) DeleteFolder(userID, folderID int64) error {
+	const op = "storage.postgres.DeleteFolder"
+
+	result, err := s.DB.Exec(`DELETE FROM folders WHERE id = $1 AND user_id = $2`, folderID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected(op, result, ErrFolderNotFound)
+}
+
+// FolderAliases returns refs of the links in the folder
+func (s *Storage) FolderAliases(folderID int64) ([]string, error) {
+	const op = "storage.postgres.FolderAliases"
+
+	rows, err := s.DB.Query(`SELECT ref FROM url WHERE folder_id = $1`, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var aliases []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		aliases = append(aliases, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return aliases, nil
+}
+
+// templateColumns are selected by every query that returns models.UTMTemplate
+const templateColumns = `id, name, is_default, utm_source, utm_medium, utm_campaign, utm_term, utm_content, created_at`
+
+// UTMTemplates returns campaign templates of the user ordered by name
+func (s *Storage) UTMTemplates(userID int64) ([]models.UTMTemplate, error) {
+	const op = "storage.postgres.UTMTemplates"
+
+	rows, err := s.DB.Query(`SELECT `+templateColumns+` FROM utm_templates WHERE user_id = $1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	templates := make([]models.UTMTemplate, 0)
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		templates = append(templates, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return templates, nil
+}
+
+// UTMTemplate returns a template of the user
+func (s *Storage) UTMTemplate(userID, templateID int64) (models.UTMTemplate, error) {
+	const op = "storage.postgres.UTMTemplate"
+
+	t, err := scanTemplate(s.DB.QueryRow(
+		`SELECT `+templateColumns+` FROM utm_templates WHERE id = $1 AND user_id = $2`, templateID, userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.UTMTemplate{}, ErrTemplateNotFound
+	}
+	if err != nil {
+		return models.UTMTemplate{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return t, nil
+}
+
+// DefaultUTMTemplate returns the template applied to new links of the user
+func (s *Storage) DefaultUTMTemplate(userID int64) (models.UTMTemplate, error) {
+	const op = "storage.postgres.DefaultUTMTemplate"
+
+	t, err := scanTemplate(s.DB.QueryRow(
+		`SELECT `+templateColumns+` FROM utm_templates WHERE user_id = $1 AND is_default`, userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.UTMTemplate{}, ErrTemplateNotFound
+	}
+	if err != nil {
+		return models.UTMTemplate{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return t, nil
+}
+
+// CreateUTMTemplate saves the template, a new default one replaces the previous default
+func (s *Storage) CreateUTMTemplate(userID int64, t models.UTMTemplate) (models.UTMTemplate, error) {
+	const op = "storage.postgres.CreateUTMTemplate"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return models.UTMTemplate{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if t.Default {
+		if _, err := tx.Exec(`UPDATE utm_templates SET is_default = false WHERE user_id = $1`, userID); err != nil {
+			return models.UTMTemplate{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO utm_templates(user_id, name, is_default, utm_source, utm_medium, utm_campaign, utm_term, utm_content)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`,
+		userID, t.Name, t.Default, t.UTM.Source, t.UTM.Medium, t.UTM.Campaign, t.UTM.Term, t.UTM.Content,
+	).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return models.UTMTemplate{}, uniqueErr
+		}
+		return models.UTMTemplate{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.UTMTemplate{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return t, nil
+}
+
+// UpdateUTMTemplate replaces name, parameters and the default flag of the template
+func (s *Storage) UpdateUTMTemplate(userID int64, t models.UTMTemplate) error {
+	const op = "storage.postgres.UpdateUTMTemplate"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if t.Default {
+		_, err := tx.Exec(`UPDATE utm_templates SET is_default = false WHERE user_id = $1 AND id <> $2`, userID, t.ID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE utm_templates
+		SET name = $1, is_default = $2, utm_source = $3, utm_medium = $4, utm_campaign = $5, utm_term = $6, utm_content = $7
+		WHERE id = $8 AND user_id = $9`,
+		t.Name, t.Default, t.UTM.Source, t.UTM.Medium, t.UTM.Campaign, t.UTM.Term, t.UTM.Content, t.ID, userID,
+	)
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return uniqueErr
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := affected(op, result, ErrTemplateNotFound); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteUTMTemplate(userID, templateID int64) error {
+	const op = "storage.postgres.DeleteUTMTemplate"
+
+	result, err := s.DB.Exec(`DELETE FROM utm_templates WHERE id = $1 AND user_id = $2`, templateID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected(op, result, ErrTemplateNotFound)
+}
+
+func scanTemplate(row rowScanner) (models.UTMTemplate, error) {
+	var t models.UTMTemplate
+
+	err := row.Scan(
+		&t.ID,
+		&t.Name,
+		&t.Default,
+		&t.UTM.Source,
+		&t.UTM.Medium,
+		&t.UTM.Campaign,
+		&t.UTM.Term,
+		&t.UTM.Content,
+		&t.CreatedAt,
+	)
+
+	return t, err
+}
+
+// domainColumns are selected by every query that returns models.Domain, see scanDomain
+const domainColumns = `id, user_id, host, token, verified_at, COALESCE(not_found_url, ''), created_at`
+
+// Domains returns domains of the user ordered by host
+func (s *Storage) Domains(userID int64) ([]models.Domain, error) {
+	const op = "storage.postgres.Domains"
+
+	rows, err := s.DB.Query(`SELECT `+domainColumns+` FROM domains WHERE user_id = $1 ORDER BY host`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	domains := make([]models.Domain, 0)
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		domains = append(domains, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return domains, nil
+}
+
+// Domain returns the verified domain serving the host
+func (s *Storage) Domain(host string) (models.Domain, error) {
+	const op = "storage.postgres.Domain"
+
+	d, err := scanDomain(s.DB.QueryRow(
+		`SELECT `+domainColumns+` FROM domains WHERE host = $1 AND verified_at IS NOT NULL`, host,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Domain{}, ErrDomainNotFound
+	}
+	if err != nil {
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+// UserDomain returns a domain of the user, verified or not
+func (s *Storage) UserDomain(userID, domainID int64) (models.Domain, error) {
+	const op = "storage.postgres.UserDomain"
+
+	d, err := scanDomain(s.DB.QueryRow(
+		`SELECT `+domainColumns+` FROM domains WHERE id = $1 AND user_id = $2`, domainID, userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Domain{}, ErrDomainNotFound
+	}
+	if err != nil {
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+// CreateDomain adds an unverified domain to the user
+func (s *Storage) CreateDomain(userID int64, d models.Domain) (models.Domain, error) {
+	const op = "storage.postgres.CreateDomain"
+
+	d, err := scanDomain(s.DB.QueryRow(`
+		INSERT INTO domains(user_id, host, token, not_found_url) VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING `+domainColumns,
+		userID, d.Host, d.Token, d.NotFoundURL,
+	))
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return models.Domain{}, uniqueErr
+		}
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+// VerifyDomain marks the domain of the user as verified. It returns ErrDomainTaken
+// when another user has already verified the same host
+func (s *Storage) VerifyDomain(userID, domainID int64, at time.Time) (models.Domain, error) {
+	const op = "storage.postgres.VerifyDomain"
+
+	d, err := scanDomain(s.DB.QueryRow(`
+		UPDATE domains SET verified_at = COALESCE(verified_at, $1) WHERE id = $2 AND user_id = $3
+		RETURNING `+domainColumns,
+		at, domainID, userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Domain{}, ErrDomainNotFound
+	}
+	if err != nil {
+		if uniqueErr := uniqueViolation(err); uniqueErr != nil {
+			return models.Domain{}, uniqueErr
+		}
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+// SetDomainNotFoundURL changes where visitors of missing aliases of the domain go, empty turns it off
+func (s *Storage) SetDomainNotFoundURL(userID, domainID int64, url string) (models.Domain, error) {
+	const op = "storage.postgres.SetDomainNotFoundURL"
+
+	d, err := scanDomain(s.DB.QueryRow(`
+		UPDATE domains SET not_found_url = NULLIF($1, '') WHERE id = $2 AND user_id = $3
+		RETURNING `+domainColumns,
+		url, domainID, userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Domain{}, ErrDomainNotFound
+	}
+	if err != nil {
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+// DeleteDomain removes the domain of the user and returns it.
+// Domains with links, including the ones in the trash, are kept and ErrDomainInUse is returned
+func (s *Storage) DeleteDomain(userID, domainID int64) (models.Domain, error) {
+	const op = "storage.postgres.DeleteDomain"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	d, err := scanDomain(tx.QueryRow(
+		`SELECT `+domainColumns+` FROM domains WHERE id = $1 AND user_id = $2 FOR UPDATE`, domainID, userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Domain{}, ErrDomainNotFound
+	}
+	if err != nil {
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var inUse bool
+
+	err = tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM url WHERE domain = $1 AND user_id = $2)`, d.Host, userID,
+	).Scan(&inUse)
+	if err != nil {
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if inUse {
+		return models.Domain{}, ErrDomainInUse
+	}
+
+	if _, err := tx.Exec(`DELETE FROM domains WHERE id = $1`, domainID); err != nil {
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+func scanDomain(row rowScanner) (models.Domain, error) {
+	var (
+		d          models.Domain
+		verifiedAt sql.NullTime
+	)
+
+	err := row.Scan(&d.ID, &d.UserID, &d.Host, &d.Token, &verifiedAt, &d.NotFoundURL, &d.CreatedAt)
+	if err != nil {
+		return models.Domain{}, err
+	}
+
+	if verifiedAt.Valid {
+		d.VerifiedAt = &verifiedAt.Time
+	}
+
+	return d, nil
+}
+
+// affected returns notFound when the statement changed no rows
+func affected(op string, result sql.Result, notFound error) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// hostExpr extracts lower-cased host from the target url
+const hostExpr = `lower(substring(url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)'))`
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// linkColumns are selected by every query that returns models.Link, see scanLink
+const linkColumns = `id, alias, domain, url, user_id, created_at, expires_at, max_clicks, clicks,
+	password_hash IS NOT NULL, folder_id, deleted_at, COALESCE(redirect_code, 0), forward_query, forward_path,
+	not_before, paused, title, description, always_preview,
+	app_uri, COALESCE(ios_store_url, ''), COALESCE(android_store_url, ''), page_theme,
+	ARRAY(SELECT t.name FROM url_tags ut JOIN tags t ON t.id = ut.tag_id WHERE ut.url_id = url.id ORDER BY t.name)`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLink(row rowScanner) (models.Link, error) {
+	var (
+		link      models.Link
+		expiresAt sql.NullTime
+		maxClicks sql.NullInt64
+		folderID  sql.NullInt64
+		deletedAt sql.NullTime
+		notBefore sql.NullTime
+		appURI    sql.NullString
+		deepLink  models.DeepLink
+		pageTheme sql.NullString
+	)
+
+	err := row.Scan(
+		&link.ID,
+		&link.Alias,
+		&link.Domain,
+		&link.URL,
+		&link.UserID,
+		&link.CreatedAt,
+		&expiresAt,
+		&maxClicks,
+		&link.Clicks,
+		&link.Protected,
+		&folderID,
+		&deletedAt,
+		&link.RedirectCode,
+		&link.ForwardQuery,
+		&link.ForwardPath,
+		&notBefore,
+		&link.Paused,
+		&link.Title,
+		&link.Description,
+		&link.AlwaysPreview,
+		&appURI,
+		&deepLink.IOSStoreURL,
+		&deepLink.AndroidStoreURL,
+		&pageTheme,
+		pq.Array(&link.Tags),
+	)
+	if err != nil {
+		return models.Link{}, err
+	}
+
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	if maxClicks.Valid {
+		link.MaxClicks = &maxClicks.Int64
+	}
+	if folderID.Valid {
+		link.FolderID = &folderID.Int64
+	}
+	if deletedAt.Valid {
+		link.DeletedAt = &deletedAt.Time
+	}
+	if notBefore.Valid {
+		link.NotBefore = &notBefore.Time
+	}
+	if appURI.Valid {
+		deepLink.AppURI = appURI.String
+		link.DeepLink = &deepLink
+	}
+	if pageTheme.Valid {
+		link.Page = &models.Page{Theme: pageTheme.String}
+	}
+	if utm := models.ParseUTM(link.URL); !utm.Empty() {
+		link.UTM = &utm
+	}
+
+	return link, nil
 }
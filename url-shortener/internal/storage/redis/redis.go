@@ -0,0 +1,22 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/lostmyescape/link-shortener/url-shortener/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+func NewClient(cfg *config.Config) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.GetRedisAddr(),
+		Password: cfg.GetRedisPassword(),
+		DB:       cfg.RedisStorage.DB,
+	})
+
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		panic("failed connect to Redis")
+	}
+
+	return rdb
+}
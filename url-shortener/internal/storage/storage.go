@@ -3,8 +3,24 @@ package storage
 import "errors"
 
 var (
-	ErrURLNotFound   = errors.New("url not found")
-	ErrURLExists     = errors.New("URL already exist")
-	ErrAliasExists   = errors.New("alias already exists")
-	ErrAliasNotFound = errors.New("alias not found")
+	ErrURLNotFound      = errors.New("url not found")
+	ErrURLExists        = errors.New("URL already exist")
+	ErrAliasExists      = errors.New("alias already exists")
+	ErrAliasNotFound    = errors.New("alias not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrRuleNotFound     = errors.New("rule not found")
+	ErrTagExists        = errors.New("tag already exists")
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrFolderExists     = errors.New("folder already exists")
+	ErrFolderNotFound   = errors.New("folder not found")
+	ErrTemplateExists   = errors.New("template already exists")
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrPageNotFound     = errors.New("page not found")
+	ErrPageItemNotFound = errors.New("page item not found")
+	ErrDomainExists     = errors.New("domain already exists")
+	ErrDomainNotFound   = errors.New("domain not found")
+	ErrDomainTaken      = errors.New("domain is verified by another user")
+	ErrDomainInUse      = errors.New("domain has links")
+	ErrLinkExhausted    = errors.New("link has no clicks left")
+	ErrBatchRejected    = errors.New("batch rejected")
 )
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS url_variants (
+    id SERIAL PRIMARY KEY,
+    url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    weight INTEGER NOT NULL CHECK (weight > 0),
+    UNIQUE (url_id, name)
+);
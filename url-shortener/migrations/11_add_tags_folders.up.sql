@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS folders (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT folders_user_name_key UNIQUE (user_id, name)
+);
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT tags_user_name_key UNIQUE (user_id, name)
+);
+CREATE TABLE IF NOT EXISTS url_tags (
+    url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (url_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS idx_url_tags_tag_id ON url_tags(tag_id);
+ALTER TABLE url ADD COLUMN IF NOT EXISTS folder_id INTEGER REFERENCES folders(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_url_folder_id ON url(folder_id);
@@ -0,0 +1,5 @@
+DELETE FROM url WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS url_user_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS url_user_url_key ON url(user_id, url);
+DROP INDEX IF EXISTS idx_url_deleted_at;
+ALTER TABLE url DROP COLUMN IF EXISTS deleted_at;
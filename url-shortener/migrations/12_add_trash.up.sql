@@ -0,0 +1,5 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_url_deleted_at ON url(deleted_at) WHERE deleted_at IS NOT NULL;
+-- links in the trash keep their alias but do not block shortening the same target again
+DROP INDEX IF EXISTS url_user_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS url_user_url_key ON url(user_id, url) WHERE deleted_at IS NULL;
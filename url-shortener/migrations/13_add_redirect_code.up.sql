@@ -0,0 +1,2 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS redirect_code SMALLINT
+    CHECK (redirect_code IN (301, 302, 307, 308));
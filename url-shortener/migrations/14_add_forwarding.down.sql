@@ -0,0 +1,2 @@
+ALTER TABLE url DROP COLUMN IF EXISTS forward_path;
+ALTER TABLE url DROP COLUMN IF EXISTS forward_query;
@@ -0,0 +1,2 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS forward_query BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS forward_path BOOLEAN NOT NULL DEFAULT false;
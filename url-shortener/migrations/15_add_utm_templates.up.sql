@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS utm_templates (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    utm_source TEXT NOT NULL DEFAULT '',
+    utm_medium TEXT NOT NULL DEFAULT '',
+    utm_campaign TEXT NOT NULL DEFAULT '',
+    utm_term TEXT NOT NULL DEFAULT '',
+    utm_content TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT utm_templates_user_name_key UNIQUE (user_id, name)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS utm_templates_user_default_key ON utm_templates(user_id) WHERE is_default;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_url_not_before;
+
+ALTER TABLE url DROP COLUMN IF EXISTS paused;
+ALTER TABLE url DROP COLUMN IF EXISTS activated_at;
+ALTER TABLE url DROP COLUMN IF EXISTS not_before;
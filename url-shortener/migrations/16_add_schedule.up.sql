@@ -0,0 +1,5 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS not_before TIMESTAMPTZ;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS activated_at TIMESTAMPTZ;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS paused BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_url_not_before ON url(not_before) WHERE not_before IS NOT NULL AND activated_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE url DROP COLUMN IF EXISTS always_preview;
+ALTER TABLE url DROP COLUMN IF EXISTS description;
+ALTER TABLE url DROP COLUMN IF EXISTS title;
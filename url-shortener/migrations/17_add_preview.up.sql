@@ -0,0 +1,3 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS title TEXT NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN IF NOT EXISTS always_preview BOOLEAN NOT NULL DEFAULT false;
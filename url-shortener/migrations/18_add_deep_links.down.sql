@@ -0,0 +1,3 @@
+ALTER TABLE url DROP COLUMN IF EXISTS android_store_url;
+ALTER TABLE url DROP COLUMN IF EXISTS ios_store_url;
+ALTER TABLE url DROP COLUMN IF EXISTS app_uri;
@@ -0,0 +1,3 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS app_uri TEXT;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS ios_store_url TEXT;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS android_store_url TEXT;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS page_items;
+
+ALTER TABLE url DROP COLUMN IF EXISTS page_theme;
@@ -0,0 +1,10 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS page_theme TEXT;
+
+CREATE TABLE IF NOT EXISTS page_items (
+    page_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (page_id, url_id)
+);
+CREATE INDEX IF NOT EXISTS idx_page_items_url_id ON page_items(url_id);
@@ -0,0 +1,11 @@
+DELETE FROM url WHERE domain <> '';
+
+DROP INDEX IF EXISTS url_user_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS url_user_url_key ON url(user_id, url) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS url_ref_key;
+ALTER TABLE url ADD CONSTRAINT url_alias_key UNIQUE (alias);
+ALTER TABLE url DROP COLUMN IF EXISTS ref;
+ALTER TABLE url DROP COLUMN IF EXISTS domain;
+
+DROP TABLE IF EXISTS domains;
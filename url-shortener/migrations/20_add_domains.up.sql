@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS domains (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    host TEXT NOT NULL,
+    token TEXT NOT NULL,
+    not_found_url TEXT,
+    verified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT domains_user_host_key UNIQUE (user_id, host)
+);
+-- any user may claim a host, but only one of them can prove it
+CREATE UNIQUE INDEX IF NOT EXISTS domains_verified_host_key ON domains(host) WHERE verified_at IS NOT NULL;
+
+-- aliases are unique per domain, the empty domain is the one of the service
+ALTER TABLE url ADD COLUMN IF NOT EXISTS domain TEXT NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN IF NOT EXISTS ref TEXT
+    GENERATED ALWAYS AS (CASE WHEN domain = '' THEN alias ELSE domain || '/' || alias END) STORED;
+ALTER TABLE url DROP CONSTRAINT IF EXISTS url_alias_key;
+CREATE UNIQUE INDEX IF NOT EXISTS url_ref_key ON url(ref);
+
+DROP INDEX IF EXISTS url_user_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS url_user_url_key ON url(user_id, domain, url) WHERE deleted_at IS NULL;
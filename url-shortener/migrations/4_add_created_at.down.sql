@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_url_user_created;
+ALTER TABLE url DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS url_revisions (
+    id SERIAL PRIMARY KEY,
+    url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    changed_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_url_revisions_url_id ON url_revisions(url_id, id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS url_archive;
+DROP INDEX IF EXISTS idx_url_max_clicks;
+DROP INDEX IF EXISTS idx_url_expires_at;
+ALTER TABLE url DROP COLUMN IF EXISTS clicks;
+ALTER TABLE url DROP COLUMN IF EXISTS max_clicks;
+ALTER TABLE url DROP COLUMN IF EXISTS expires_at;
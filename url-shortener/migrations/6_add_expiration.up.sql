@@ -0,0 +1,17 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS max_clicks BIGINT;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS clicks BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_url_expires_at ON url(expires_at) WHERE expires_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_url_max_clicks ON url(id) WHERE max_clicks IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS url_archive (
+    id INTEGER PRIMARY KEY,
+    alias TEXT NOT NULL,
+    url TEXT NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    max_clicks BIGINT,
+    clicks BIGINT NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS password_hash BYTEA;
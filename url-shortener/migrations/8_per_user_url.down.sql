@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS url_user_url_key;
+ALTER TABLE url ADD CONSTRAINT url_url_key UNIQUE (url);
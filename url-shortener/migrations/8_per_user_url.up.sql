@@ -0,0 +1,2 @@
+ALTER TABLE url DROP CONSTRAINT IF EXISTS url_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS url_user_url_key ON url(user_id, url);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS url_rules (
+    id SERIAL PRIMARY KEY,
+    url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    platform TEXT NOT NULL DEFAULT '',
+    language TEXT NOT NULL DEFAULT '',
+    country TEXT NOT NULL DEFAULT '',
+    time_from TEXT NOT NULL DEFAULT '',
+    time_to TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_url_rules_url_id ON url_rules(url_id, position, id);
@@ -84,6 +84,7 @@ func TestURLShortener_SaveRedirect(t *testing.T) {
 		url      string
 		alias    string
 		error    string
+		existing bool
 		wantCode int
 	}{
 		{
@@ -109,6 +110,13 @@ func TestURLShortener_SaveRedirect(t *testing.T) {
 			name:     "URL already exists",
 			url:      existingURL,
 			alias:    "",
+			existing: true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "URL already exists under another alias",
+			url:      existingURL,
+			alias:    gofakeit.Word() + gofakeit.Word(),
 			error:    "URL already exists",
 			wantCode: http.StatusConflict,
 		},
@@ -133,6 +141,14 @@ func TestURLShortener_SaveRedirect(t *testing.T) {
 				return
 			}
 
+			// повторное сокращение отдает уже существующий alias
+			if tc.existing {
+				resp.Value("existing").Boolean().IsTrue()
+				resp.Value("Alias").String().IsEqual(existingAlias)
+				testRedirect(t, existingAlias, tc.url)
+				return
+			}
+
 			alias := tc.alias
 
 			if tc.alias != "" {